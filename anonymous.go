@@ -29,3 +29,13 @@ type HTTPMethodPOST struct {
 type HTTPMethodPUT struct {
 	_ string `api:"httpmethod:PUT"`
 }
+
+// HTTPMethodWEBSOCKET marks this endpoint as a WebSocket endpoint.
+//
+// The endpoint is registered as GET; once the metadata has been bound from the upgrade request (and validated),
+// the connection is upgraded and the method is called with its WebSocket field.  Any error before the upgrade is
+// written as usual.  When the method returns, the connection is closed; if the method returned an error, then it
+// is logged and the close status is "internal error".
+type HTTPMethodWEBSOCKET struct {
+	_ string `api:"httpmethod:WEBSOCKET"`
+}
@@ -0,0 +1,76 @@
+package restfulwrapper
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+)
+
+// BodyDecoder decodes a request body into the target given.
+//
+// The target will always be a non-nil pointer to the "body" field.
+type BodyDecoder func(body io.Reader, target any) error
+
+// registeredBodyCodecMap is the map of registered content types to their body decoders.
+var registeredBodyCodecMap = map[string]BodyDecoder{}
+
+// RegisterBodyCodec registers a decoder for a content type (such as "application/cbor") for use with the "body" tag.
+//
+// Any "body" field that would otherwise be read with restful's ReadEntity (that is, anything other than a string,
+// a byte slice, or one of the form types) will be decoded with this decoder when the request has this content type.
+// If the "body" tag does not set its own content types, then this content type will be added to the route's
+// Consumes (and thus its documentation) in addition to the wrapper's.
+//
+// Each wrapper uses the codecs that were registered when it was created (with WebService), so codecs must be
+// registered before then; typically, this is done in an "init" function.
+//
+// This will panic if the content type is invalid or already registered.
+func RegisterBodyCodec(contentType string, decoder BodyDecoder) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		panic(fmt.Errorf("invalid body codec content type: %s: %w", contentType, err))
+	}
+	if _, ok := registeredBodyCodecMap[mediaType]; ok {
+		panic(fmt.Errorf("body codec already registered: %s", mediaType))
+	}
+	registeredBodyCodecMap[mediaType] = decoder
+}
+
+// ResponseEncoder encodes a response value to the writer given.
+//
+// The value may be nil if the method has no output (or returned nil).
+type ResponseEncoder func(w io.Writer, value any) error
+
+// registeredResponseEncoderMap is the map of registered content types to their response encoders.
+var registeredResponseEncoderMap = map[string]ResponseEncoder{}
+
+// RegisterResponseEncoder registers an encoder for a content type (such as "text/csv") for use with method outputs.
+//
+// The content type of each response is negotiated from the request's "Accept" header and the route's Produces, so
+// the content type must also be added to the Produces of any route (or wrapper) that should use it.  Content types
+// without a registered encoder (such as JSON) are written with restful's own entity writers.
+//
+// Encoders must be registered before any requests are served; typically, this is done in an "init" function.
+//
+// This will panic if the content type is invalid or already registered.
+func RegisterResponseEncoder(contentType string, encoder ResponseEncoder) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		panic(fmt.Errorf("invalid response encoder content type: %s: %w", contentType, err))
+	}
+	if _, ok := registeredResponseEncoderMap[mediaType]; ok {
+		panic(fmt.Errorf("response encoder already registered: %s", mediaType))
+	}
+	registeredResponseEncoderMap[mediaType] = encoder
+}
+
+// bodyCodecContentTypes returns the content types of the body codecs given, in sorted order.
+func bodyCodecContentTypes(bodyCodecs map[string]BodyDecoder) []string {
+	var contentTypes []string
+	for contentType := range bodyCodecs {
+		contentTypes = append(contentTypes, contentType)
+	}
+	slices.Sort(contentTypes)
+	return contentTypes
+}
@@ -0,0 +1,230 @@
+package restfulwrapper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codecTestBody struct {
+	Names []string `json:"names"`
+}
+
+type codecTestMetadata struct {
+	HTTPMethodPOST
+	_    string        `api:"httppath:/names"`
+	Body codecTestBody `api:"body"`
+}
+
+func TestRegisterBodyCodec(t *testing.T) {
+	ctx := t.Context()
+
+	// This wrapper is created before the codec is registered, so it should not use it.
+	earlierWebService := WebService("/earlier").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	RegisterBodyCodec("text/x-names; charset=utf-8", func(body io.Reader, target any) error {
+		contents, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		target.(*codecTestBody).Names = strings.Split(string(contents), "\n")
+		return nil
+	})
+	t.Cleanup(func() {
+		delete(registeredBodyCodecMap, "text/x-names")
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterBodyCodec("text/x-names", func(body io.Reader, target any) error { return nil })
+		})
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterBodyCodec("bogus/", func(body io.Reader, target any) error { return nil })
+		})
+	})
+
+	webService := WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	Handle(ctx, webService, "/v1", func(ctx context.Context, meta codecTestMetadata) (string, error) {
+		return strings.Join(meta.Body.Names, ","), nil
+	})
+	Handle(ctx, earlierWebService, "/v1", func(ctx context.Context, meta codecTestMetadata) (string, error) {
+		return strings.Join(meta.Body.Names, ","), nil
+	})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+	container.Add(earlierWebService.WebService())
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	t.Run("Consumes", func(t *testing.T) {
+		routes := webService.Routes()
+		require.Equal(t, 1, len(routes))
+		assert.Equal(t, []string{restful.MIME_JSON, "text/x-names"}, routes[0].Consumes)
+	})
+	t.Run("Earlier wrapper", func(t *testing.T) {
+		routes := earlierWebService.Routes()
+		require.Equal(t, 1, len(routes))
+		assert.NotContains(t, routes[0].Consumes, "text/x-names")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/earlier/v1/names", strings.NewReader("c\nd"))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "text/x-names")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+
+	rows := []struct {
+		ContentType string
+		Body        string
+		Code        int
+		Output      string
+	}{
+		{ContentType: "application/json", Body: `{"names":["a","b"]}`, Code: http.StatusOK, Output: `"a,b"`},
+		{ContentType: "text/x-names", Body: "c\nd", Code: http.StatusOK, Output: `"c,d"`},
+		{ContentType: "text/plain", Body: "e", Code: http.StatusUnsupportedMediaType},
+	}
+	for _, row := range rows {
+		t.Run(row.ContentType, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/names", strings.NewReader(row.Body))
+			require.Nil(t, err)
+
+			req.Header.Set("Content-Type", row.ContentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, row.Code, resp.StatusCode)
+			if row.Code == http.StatusOK {
+				bodyBytes, err := io.ReadAll(resp.Body)
+				require.Nil(t, err)
+				assert.Equal(t, row.Output, string(bodyBytes))
+			}
+		})
+	}
+}
+
+type encoderTestOutput struct {
+	Names []string `json:"names"`
+}
+
+type encoderTestMetadata struct {
+	HTTPMethodGET
+	_ string `api:"httppath:/names"`
+}
+
+func TestRegisterResponseEncoder(t *testing.T) {
+	ctx := t.Context()
+
+	RegisterResponseEncoder("text/x-names; charset=utf-8", func(w io.Writer, value any) error {
+		_, err := io.WriteString(w, strings.Join(value.(encoderTestOutput).Names, "\n"))
+		return err
+	})
+	t.Cleanup(func() {
+		delete(registeredResponseEncoderMap, "text/x-names")
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterResponseEncoder("text/x-names", func(w io.Writer, value any) error { return nil })
+		})
+	})
+
+	webService := WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON, "text/x-names")
+	Handle(ctx, webService, "/v1", func(ctx context.Context, meta encoderTestMetadata) (encoderTestOutput, error) {
+		return encoderTestOutput{Names: []string{"a", "b"}}, nil
+	})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	rows := []struct {
+		Accept      string
+		Code        int
+		ContentType string
+		Output      string
+	}{
+		{Accept: "", Code: http.StatusOK, ContentType: "application/json", Output: `{"names":["a","b"]}`},
+		{Accept: "application/json", Code: http.StatusOK, ContentType: "application/json", Output: `{"names":["a","b"]}`},
+		{Accept: "text/x-names", Code: http.StatusOK, ContentType: "text/x-names", Output: "a\nb"},
+		{Accept: "text/x-names;q=0.5, application/json", Code: http.StatusOK, ContentType: "application/json", Output: `{"names":["a","b"]}`},
+		{Accept: "text/x-names, application/json;q=0.5", Code: http.StatusOK, ContentType: "text/x-names", Output: "a\nb"},
+		{Accept: "text/x-names;q=0", Code: http.StatusNotAcceptable, ContentType: "application/json"},
+	}
+	for _, row := range rows {
+		t.Run(row.Accept, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/names", nil)
+			require.Nil(t, err)
+
+			if row.Accept != "" {
+				req.Header.Set("Accept", row.Accept)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err)
+			defer resp.Body.Close()
+
+			require.Equal(t, row.Code, resp.StatusCode)
+			assert.Equal(t, row.ContentType, resp.Header.Get("Content-Type"))
+			if row.Code == http.StatusOK {
+				bodyBytes, err := io.ReadAll(resp.Body)
+				require.Nil(t, err)
+				if row.ContentType == restful.MIME_JSON {
+					assert.JSONEq(t, row.Output, string(bodyBytes))
+				} else {
+					assert.Equal(t, row.Output, string(bodyBytes))
+				}
+			}
+		})
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	rows := []struct {
+		Accept   string
+		Produces []string
+		Success  bool
+		Output   string
+	}{
+		{Accept: "", Produces: []string{"application/json", "text/csv"}, Success: true, Output: "application/json"},
+		{Accept: "*/*", Produces: []string{"application/json", "text/csv"}, Success: true, Output: "application/json"},
+		{Accept: "text/*", Produces: []string{"application/json", "text/csv"}, Success: true, Output: "text/csv"},
+		{Accept: "text/csv;q=0.9, */*;q=0.1", Produces: []string{"application/json", "text/csv"}, Success: true, Output: "text/csv"},
+		{Accept: "application/json;q=0.1, text/csv;q=0.9", Produces: []string{"application/json", "text/csv"}, Success: true, Output: "text/csv"},
+		{Accept: "text/csv; charset=utf-8", Produces: []string{"text/csv; charset=utf-8"}, Success: true, Output: "text/csv"},
+		{Accept: "text/csv;q=0", Produces: []string{"application/json", "text/csv"}, Success: false},
+		{Accept: "image/png", Produces: []string{"application/json", "text/csv"}, Success: false},
+	}
+	for _, row := range rows {
+		t.Run(row.Accept, func(t *testing.T) {
+			output, ok := negotiateContentType(row.Accept, row.Produces)
+			require.Equal(t, row.Success, ok)
+			assert.Equal(t, row.Output, output)
+		})
+	}
+}
@@ -23,6 +23,19 @@ type ErrorWriter interface {
 type APIResponseErrorOutput struct {
 	Type    string `json:"type,omitempty"`
 	Message string `json:"message"`
+	ErrorID string `json:"errorId,omitempty"` // This is the ID of a hidden internal error; see RestfulWrapper.HideInternalErrors.
+}
+
+// APICookieParameterErrorOutput is the output structure for a cookie parameter error.
+type APICookieParameterErrorOutput struct {
+	APIResponseErrorOutput
+	Parameter string `json:"parameter"`
+}
+
+// APIFormParameterErrorOutput is the output structure for a form parameter error.
+type APIFormParameterErrorOutput struct {
+	APIResponseErrorOutput
+	Parameter string `json:"parameter"`
 }
 
 // APIHeaderParameterErrorOutput is the output structure for a header parameter error.
@@ -53,6 +66,7 @@ type APIBodyError struct {
 
 var _ error = (*APIBodyError)(nil)
 var _ ErrorWriter = (*APIBodyError)(nil)
+var _ problemDetailer = (*APIBodyError)(nil)
 
 func (e *APIBodyError) Error() string {
 	return e.bodyError.Error()
@@ -66,6 +80,14 @@ func (e *APIBodyError) WriteError(resp *restful.Response) {
 	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
 }
 
+func (e *APIBodyError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status: e.apiResponseError.Code(),
+		Detail: e.apiResponseError.message,
+		In:     "body",
+	}
+}
+
 func (e *APIBodyError) Unwrap() []error {
 	return []error{e.bodyError, e.apiResponseError}
 }
@@ -84,6 +106,122 @@ func NewAPIBodyError(bodyError error) error {
 	return err
 }
 
+// APICookieParameterError is an error that represents a cookie parameter error.
+//
+// This will always be a 400-level error.
+type APICookieParameterError struct {
+	parameter        string
+	parameterError   error
+	apiResponseError *APIResponseError
+}
+
+var _ error = (*APICookieParameterError)(nil)
+var _ ErrorWriter = (*APICookieParameterError)(nil)
+var _ problemDetailer = (*APICookieParameterError)(nil)
+
+func (e *APICookieParameterError) Error() string {
+	return e.parameterError.Error()
+}
+
+func (e *APICookieParameterError) WriteError(resp *restful.Response) {
+	output := APICookieParameterErrorOutput{
+		APIResponseErrorOutput: APIResponseErrorOutput{
+			Type:    fmt.Sprintf("%T", e),
+			Message: e.apiResponseError.message,
+		},
+		Parameter: e.parameter,
+	}
+	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
+}
+
+func (e *APICookieParameterError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status:    e.apiResponseError.Code(),
+		Detail:    e.apiResponseError.message,
+		Parameter: e.parameter,
+		In:        "cookie",
+	}
+}
+
+func (e *APICookieParameterError) Unwrap() []error {
+	return []error{e.parameterError, e.apiResponseError}
+}
+
+// NewAPICookieParameterError returns a new cookie parameter error.
+//
+// Call this any time there is any issue at all with a cookie parameter.
+// For example, if it is required but missing; if it has an incorrect value; or
+// if it needed to be parsed and could not be parsed.
+func NewAPICookieParameterError(parameter string, parameterError error) error {
+	err := &APICookieParameterError{
+		parameter:      parameter,
+		parameterError: parameterError,
+		apiResponseError: &APIResponseError{
+			message:   parameterError.Error(),
+			httpError: httperror.ErrorFromStatus(http.StatusBadRequest),
+		},
+	}
+	return err
+}
+
+// APIFormParameterError is an error that represents a form parameter error.
+//
+// This will always be a 400-level error.
+type APIFormParameterError struct {
+	parameter        string
+	parameterError   error
+	apiResponseError *APIResponseError
+}
+
+var _ error = (*APIFormParameterError)(nil)
+var _ ErrorWriter = (*APIFormParameterError)(nil)
+var _ problemDetailer = (*APIFormParameterError)(nil)
+
+func (e *APIFormParameterError) Error() string {
+	return e.parameterError.Error()
+}
+
+func (e *APIFormParameterError) WriteError(resp *restful.Response) {
+	output := APIFormParameterErrorOutput{
+		APIResponseErrorOutput: APIResponseErrorOutput{
+			Type:    fmt.Sprintf("%T", e),
+			Message: e.apiResponseError.message,
+		},
+		Parameter: e.parameter,
+	}
+	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
+}
+
+func (e *APIFormParameterError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status:    e.apiResponseError.Code(),
+		Detail:    e.apiResponseError.message,
+		Parameter: e.parameter,
+		In:        "form",
+	}
+}
+
+func (e *APIFormParameterError) Unwrap() []error {
+	return []error{e.parameterError, e.apiResponseError}
+}
+
+// NewAPIFormParameterError returns a new form parameter error.
+//
+// Call this any time there is any issue at all with a form parameter.
+// For example, if it is required but missing; if it has an incorrect value; or
+// if it needed to be parsed and could not be parsed.
+func NewAPIFormParameterError(parameter string, parameterError error) error {
+	err := &APIFormParameterError{
+		parameter:      parameter,
+		parameterError: parameterError,
+		apiResponseError: &APIResponseError{
+			message:   parameterError.Error(),
+			httpError: httperror.ErrorFromStatus(http.StatusBadRequest),
+		},
+	}
+	return err
+}
+
 // APIHeaderParameterError is an error that represents a header parameter error.
 //
 // This will always be a 400-level error.
@@ -95,6 +233,7 @@ type APIHeaderParameterError struct {
 
 var _ error = (*APIHeaderParameterError)(nil)
 var _ ErrorWriter = (*APIHeaderParameterError)(nil)
+var _ problemDetailer = (*APIHeaderParameterError)(nil)
 
 func (e *APIHeaderParameterError) Error() string {
 	return e.parameterError.Error()
@@ -110,6 +249,15 @@ func (e *APIHeaderParameterError) WriteError(resp *restful.Response) {
 	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
 }
 
+func (e *APIHeaderParameterError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status:    e.apiResponseError.Code(),
+		Detail:    e.apiResponseError.message,
+		Parameter: e.parameter,
+		In:        "header",
+	}
+}
+
 func (e *APIHeaderParameterError) Unwrap() []error {
 	return []error{e.parameterError, e.apiResponseError}
 }
@@ -142,6 +290,7 @@ type APIPathParameterError struct {
 
 var _ error = (*APIPathParameterError)(nil)
 var _ ErrorWriter = (*APIPathParameterError)(nil)
+var _ problemDetailer = (*APIPathParameterError)(nil)
 
 func (e *APIPathParameterError) Error() string {
 	return e.parameterError.Error()
@@ -157,6 +306,15 @@ func (e *APIPathParameterError) WriteError(resp *restful.Response) {
 	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
 }
 
+func (e *APIPathParameterError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status:    e.apiResponseError.Code(),
+		Detail:    e.apiResponseError.message,
+		Parameter: e.parameter,
+		In:        "path",
+	}
+}
+
 func (e *APIPathParameterError) Unwrap() []error {
 	return []error{e.parameterError, e.apiResponseError}
 }
@@ -189,6 +347,7 @@ type APIQueryParameterError struct {
 
 var _ error = (*APIQueryParameterError)(nil)
 var _ ErrorWriter = (*APIQueryParameterError)(nil)
+var _ problemDetailer = (*APIQueryParameterError)(nil)
 
 func (e *APIQueryParameterError) Error() string {
 	return e.parameterError.Error()
@@ -205,6 +364,15 @@ func (e *APIQueryParameterError) WriteError(resp *restful.Response) {
 	resp.WriteHeaderAndEntity(e.apiResponseError.Code(), output)
 }
 
+func (e *APIQueryParameterError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status:    e.apiResponseError.Code(),
+		Detail:    e.apiResponseError.message,
+		Parameter: e.parameter,
+		In:        "query",
+	}
+}
+
 func (e *APIQueryParameterError) Unwrap() []error {
 	return []error{e.parameterError, e.apiResponseError}
 }
@@ -236,6 +404,7 @@ type APIResponseError struct {
 
 var _ error = (*APIResponseError)(nil)
 var _ ErrorWriter = (*APIResponseError)(nil)
+var _ problemDetailer = (*APIResponseError)(nil)
 
 func (e *APIResponseError) Error() string {
 	return e.message
@@ -249,6 +418,13 @@ func (e *APIResponseError) WriteError(resp *restful.Response) {
 	resp.WriteHeaderAndEntity(e.Code(), output)
 }
 
+func (e *APIResponseError) problemDetails() ProblemDetailsOutput {
+	return ProblemDetailsOutput{
+		Status: e.Code(),
+		Detail: e.message,
+	}
+}
+
 func (e *APIResponseError) Unwrap() error {
 	return e.httpError
 }
@@ -25,6 +25,34 @@ func TestError(t *testing.T) {
 			assert.Equal(t, input, baseErr.bodyError)
 		}
 	})
+	t.Run("APICookieParameterError", func(t *testing.T) {
+		input := fmt.Errorf("error-1")
+		err := NewAPICookieParameterError("key", input)
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, input)
+		assert.ErrorIs(t, err, httperror.ErrStatusBadRequest)
+		assert.Equal(t, "error-1", err.Error())
+
+		baseErr := &APICookieParameterError{}
+		if assert.ErrorAs(t, err, &baseErr) {
+			assert.Equal(t, "key", baseErr.parameter)
+			assert.Equal(t, input, baseErr.parameterError)
+		}
+	})
+	t.Run("APIFormParameterError", func(t *testing.T) {
+		input := fmt.Errorf("error-1")
+		err := NewAPIFormParameterError("key", input)
+		require.NotNil(t, err)
+		assert.ErrorIs(t, err, input)
+		assert.ErrorIs(t, err, httperror.ErrStatusBadRequest)
+		assert.Equal(t, "error-1", err.Error())
+
+		baseErr := &APIFormParameterError{}
+		if assert.ErrorAs(t, err, &baseErr) {
+			assert.Equal(t, "key", baseErr.parameter)
+			assert.Equal(t, input, baseErr.parameterError)
+		}
+	})
 	t.Run("APIHeaderParameterError", func(t *testing.T) {
 		input := fmt.Errorf("error-1")
 		err := NewAPIHeaderParameterError("key", input)
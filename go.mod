@@ -7,10 +7,10 @@ require (
 	github.com/stretchr/testify v1.11.1
 	github.com/tekkamanendless/httperror v1.0.1
 	github.com/threatmate/restapiclient v0.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
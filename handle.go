@@ -0,0 +1,95 @@
+package restfulwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Handle registers a single typed function as an endpoint.
+//
+// The metadata type is parsed exactly like the metadata parameter of a method given to Register,
+// and the path given will be used as the root for its "httppath".  Unlike Register, the function
+// is called directly (instead of through reflection), so the compiler checks its signature.
+//
+// This will panic if the function cannot be registered; see TryHandle for a version that returns an error.
+func Handle[M any, O any](ctx context.Context, r *RestfulWrapper, path string, f func(context.Context, M) (O, error)) {
+	err := TryHandle(ctx, r, path, f)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("Could not register (%T): %v", f, err))
+		panic(err)
+	}
+}
+
+// TryHandle is like Handle, but it returns an error instead of panicking.
+//
+// Any error will be a *RegisterError, just like TryRegister.
+func TryHandle[M any, O any](ctx context.Context, r *RestfulWrapper, path string, f func(context.Context, M) (O, error)) error {
+	functionName := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+
+	info, err := ParseRestfulFunction(f)
+	if err != nil {
+		failure := &RegisterFailure{
+			Method: functionName,
+			Path:   path,
+			Err:    err,
+		}
+		var fieldError *FieldError
+		if errors.As(err, &fieldError) {
+			failure.Field = fieldError.Field
+		}
+		return &RegisterError{
+			Failures: []*RegisterFailure{failure},
+		}
+	}
+	info.MethodName = functionName
+
+	routeBuilder := r.prepareRoute(ctx, path, info, createTypedFunctionWithError(info, f, r.errorHandler))
+
+	failures := r.findRouteConflicts([]pendingRoute{{info: info, routeBuilder: routeBuilder}})
+	if len(failures) > 0 {
+		return &RegisterError{
+			Failures: failures,
+		}
+	}
+
+	r.ws.Route(routeBuilder)
+	return nil
+}
+
+// createTypedFunctionWithError returns a `RestfulFunctionWithError` that calls the typed function directly.
+func createTypedFunctionWithError[M any, O any](info *RestfulFunctionInfo, f func(context.Context, M) (O, error), errorHandler ErrorHandler) RestfulFunctionWithError {
+	return func(req *restful.Request, resp *restful.Response) error {
+		ctx := req.Request.Context()
+
+		err := info.limitBody(req, resp)
+		if err != nil {
+			return applyErrorHandler(errorHandler, err)
+		}
+
+		var meta M
+		err = info.bindMetadata(req, reflect.ValueOf(&meta).Elem())
+		if err != nil {
+			return applyErrorHandler(errorHandler, err)
+		}
+		if debugEnabled(ctx) {
+			slog.DebugContext(ctx, fmt.Sprintf("Input: %+v", meta))
+		}
+
+		output, err := f(ctx, meta)
+		if err != nil {
+			return applyErrorHandler(errorHandler, err)
+		}
+
+		err = info.writeOutput(req, resp, output)
+		if err != nil {
+			return applyErrorHandler(errorHandler, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,310 @@
+package restfulwrapper_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatmate/restfulwrapper"
+)
+
+type HandleGetItemMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_       string `api:"httppath:/items/{id}"`
+	_       string `api:"doc" description:"Get an item."`
+	ID      int    `api:"path:id" description:"The item ID."`
+	Verbose bool   `api:"query:verbose" default:"false"`
+}
+
+type HandleGetItemOutput struct {
+	ID      int  `json:"id"`
+	Verbose bool `json:"verbose"`
+}
+
+type HandlePostItemMetadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_    string            `api:"httppath:/items"`
+	Body map[string]string `api:"body"`
+}
+
+type HandleCreateItemMetadata struct {
+	restfulwrapper.HTTPMethodPUT
+	_  string `api:"httppath:/items/{id}"`
+	_  string `api:"status:201"`
+	ID int    `api:"path:id"`
+}
+
+type HandleDeleteItemMetadata struct {
+	restfulwrapper.HTTPMethodDELETE
+	_  string `api:"httppath:/items/{id}"`
+	_  string `api:"status:204"`
+	ID int    `api:"path:id"`
+}
+
+type HandlePostItemOutput struct {
+	ID           int       `json:"id"`
+	Location     string    `json:"-" api:"header:Location" description:"The item URL."`
+	ETag         string    `json:"-" api:"header:ETag"`
+	LastModified time.Time `json:"-" api:"header:Last-Modified"`
+	Count        *int      `json:"-" api:"header:X-Count"`
+	Links        []string  `json:"-" api:"header:Link"`
+}
+
+type HandlePostItemWithHeadersMetadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_    string            `api:"httppath:/items/{id}"`
+	_    string            `api:"status:201"`
+	ID   int               `api:"path:id"`
+	Body map[string]string `api:"body"`
+}
+
+// HandleAcceptedOutput is an output that sets its own status code.
+type HandleAcceptedOutput struct {
+	ID int `json:"id"`
+}
+
+func (o HandleAcceptedOutput) StatusCode() int {
+	return http.StatusAccepted
+}
+
+func TestHandle(t *testing.T) {
+	ctx := t.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta HandleGetItemMetadata) (HandleGetItemOutput, error) {
+		if meta.ID == 0 {
+			return HandleGetItemOutput{}, restfulwrapper.NewAPIResponseError(http.StatusNotFound, "")
+		}
+		return HandleGetItemOutput{ID: meta.ID, Verbose: meta.Verbose}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta *HandlePostItemMetadata) (string, error) {
+		return fmt.Sprintf("created:%s", meta.Body["name"]), nil
+	})
+
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta HandleCreateItemMetadata) (any, error) {
+		if meta.ID < 0 {
+			return HandleAcceptedOutput{ID: meta.ID}, nil
+		}
+		return HandleGetItemOutput{ID: meta.ID}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta HandleDeleteItemMetadata) (*HandleGetItemOutput, error) {
+		return nil, nil
+	})
+
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta HandlePostItemWithHeadersMetadata) (*HandlePostItemOutput, error) {
+		count := 0
+		return &HandlePostItemOutput{
+			ID:           meta.ID,
+			Location:     fmt.Sprintf("/api/v1/items/%d", meta.ID),
+			LastModified: time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC),
+			Count:        &count,
+			Links:        []string{"</a>", "</b>"},
+		}, nil
+	})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	t.Run("Routes", func(t *testing.T) {
+		routes := webService.Routes()
+		if assert.Equal(t, 5, len(routes)) {
+			assert.Equal(t, "/api/v1/items/{id}", routes[0].HTTPPath)
+			assert.Nil(t, routes[0].ReceiverType)
+			assert.Contains(t, routes[0].MethodName, "TestHandle")
+		}
+	})
+	t.Run("GET /api/v1/items/5", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/items/5?verbose=true", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var output HandleGetItemOutput
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		require.Nil(t, err)
+		assert.Equal(t, HandleGetItemOutput{ID: 5, Verbose: true}, output)
+	})
+	t.Run("GET /api/v1/items/bogus", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/items/bogus", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var output map[string]string
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIPathParameterError`, output["type"])
+		assert.Equal(t, `id`, output["parameter"])
+	})
+	t.Run("GET /api/v1/items/0", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/items/0", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+	t.Run("POST /api/v1/items", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/items", strings.NewReader(`{"name":"widget"}`))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		assert.Equal(t, `"created:widget"`, string(bodyBytes))
+	})
+	t.Run("Status", func(t *testing.T) {
+		rows := []struct {
+			Method string
+			Path   string
+			Code   int
+			Output string
+		}{
+			{Method: http.MethodPut, Path: "/api/v1/items/5", Code: http.StatusCreated, Output: `{"id":5,"verbose":false}`},
+			{Method: http.MethodPut, Path: "/api/v1/items/-5", Code: http.StatusAccepted, Output: `{"id":-5}`},
+			{Method: http.MethodDelete, Path: "/api/v1/items/5", Code: http.StatusNoContent, Output: ``},
+		}
+		for _, row := range rows {
+			t.Run(row.Method+" "+row.Path, func(t *testing.T) {
+				req, err := http.NewRequestWithContext(ctx, row.Method, server.URL+row.Path, nil)
+				require.Nil(t, err)
+
+				req.Header.Set("Content-Type", "application/json")
+
+				resp, err := http.DefaultClient.Do(req)
+				require.Nil(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, row.Code, resp.StatusCode)
+
+				bodyBytes, err := io.ReadAll(resp.Body)
+				require.Nil(t, err)
+				if row.Output == "" {
+					assert.Equal(t, "", string(bodyBytes))
+				} else {
+					assert.JSONEq(t, row.Output, string(bodyBytes))
+				}
+			})
+		}
+	})
+	t.Run("Response headers", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/items/7", strings.NewReader(`{}`))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "/api/v1/items/7", resp.Header.Get("Location"))
+		assert.NotContains(t, resp.Header, "Etag")
+		assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", resp.Header.Get("Last-Modified"))
+		assert.Equal(t, []string{"0"}, resp.Header.Values("X-Count"))
+		assert.Equal(t, []string{"</a>", "</b>"}, resp.Header.Values("Link"))
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		assert.JSONEq(t, `{"id":7}`, string(bodyBytes))
+	})
+	t.Run("Status documentation", func(t *testing.T) {
+		for _, route := range webService.WebService().Routes() {
+			switch route.Method {
+			case http.MethodPost:
+				if route.Path == "/api/v1/items/{id}" && assert.Contains(t, route.ResponseErrors, http.StatusCreated) {
+					headers := route.ResponseErrors[http.StatusCreated].Headers
+					assert.Equal(t, 5, len(headers))
+					assert.Equal(t, "The item URL.", headers["Location"].Description)
+				}
+			case http.MethodPut:
+				assert.Contains(t, route.ResponseErrors, http.StatusCreated)
+				assert.NotContains(t, route.ResponseErrors, http.StatusOK)
+			case http.MethodDelete:
+				if assert.Contains(t, route.ResponseErrors, http.StatusNoContent) {
+					assert.Nil(t, route.ResponseErrors[http.StatusNoContent].Model)
+				}
+			}
+		}
+	})
+	t.Run("Bad metadata", func(t *testing.T) {
+		err := restfulwrapper.TryHandle(ctx, webService, "/v1", func(ctx context.Context, meta struct {
+			Value string `api:"bogus"`
+		}) (string, error) {
+			return "", nil
+		})
+		require.NotNil(t, err)
+
+		var registerError *restfulwrapper.RegisterError
+		if assert.ErrorAs(t, err, &registerError) && assert.Equal(t, 1, len(registerError.Failures)) {
+			assert.Nil(t, registerError.Failures[0].Type)
+			assert.Equal(t, "Value", registerError.Failures[0].Field)
+		}
+	})
+	t.Run("Conflict", func(t *testing.T) {
+		err := restfulwrapper.TryHandle(ctx, webService, "/v1", func(ctx context.Context, meta HandleGetItemMetadata) (string, error) {
+			return "", nil
+		})
+		require.NotNil(t, err)
+
+		var conflictError *restfulwrapper.RouteConflictError
+		assert.ErrorAs(t, err, &conflictError)
+	})
+}
+
+func BenchmarkHandle(b *testing.B) {
+	ctx := b.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta BenchmarkMetadata) (*BenchmarkOutput, error) {
+		return &BenchmarkOutput{ID: meta.ID, Tenant: meta.Tenant}, nil
+	})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/items/5?limit=20&tag=a&tag=b", nil)
+	req.Header.Set("X-Tenant", "tenant")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		recorder := httptest.NewRecorder()
+		container.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			b.Fatalf("unexpected status: %d", recorder.Code)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -73,6 +74,27 @@ func ParseRestfulFunction(f interface{}) (*RestfulFunctionInfo, error) {
 			exampleValue = exampleValue.Addr()
 		}
 		info.ResponseExample = exampleValue.Interface()
+
+		info.outputStream, info.outputStreamItem = parseStreamKind(argumentType)
+		switch info.outputStream {
+		case streamKindReader:
+			// There is nothing useful to say about a stream of bytes.
+			info.ResponseExample = nil
+		case streamKindSeq, streamKindChannel:
+			if info.isServerSentEventStream() {
+				// The events are described by the "text/event-stream" content type.
+				info.ResponseExample = nil
+				break
+			}
+			// The items are streamed as if they were a slice.
+			info.ResponseExample = reflect.New(reflect.SliceOf(info.outputStreamItem)).Interface()
+		}
+
+		responseHeaders, err := parseResponseHeaders(argumentType)
+		if err != nil {
+			return nil, err
+		}
+		info.ResponseHeaders = responseHeaders
 	}
 
 	if info.InMetadataPosition >= 0 {
@@ -88,23 +110,61 @@ func ParseRestfulFunction(f interface{}) (*RestfulFunctionInfo, error) {
 
 		for fieldIndex := range argumentType.NumField() {
 			field := argumentType.Field(fieldIndex)
-			err := handleField(&info, field)
+			err := handleField(&info, field, field.Index)
 			if err != nil {
-				return nil, fmt.Errorf("could not handle field %q: %w", field.Name, err)
+				return nil, &FieldError{
+					Field: field.Name,
+					Err:   err,
+				}
 			}
 		}
 	}
 
+	// A WebSocket endpoint needs a connection, and only it can have one; it has no response to write.
+	if info.WebSocket && !info.hasWebSocketField {
+		return nil, fmt.Errorf("websocket endpoint without a websocket field")
+	}
+	if !info.WebSocket && info.hasWebSocketField {
+		return nil, fmt.Errorf("websocket field without a websocket endpoint")
+	}
+	if info.WebSocket && info.OutResponsePosition >= 0 {
+		return nil, fmt.Errorf("websocket endpoint with a non-error return value")
+	}
+
+	// Server-Sent Events can only be produced as "text/event-stream".
+	if info.isServerSentEventStream() && len(info.Produces) == 0 {
+		info.Produces = []string{MIME_EVENT_STREAM}
+	}
+
 	return &info, nil
 }
 
-func handleField(info *RestfulFunctionInfo, field reflect.StructField) error {
+// FieldError is returned by ParseRestfulFunction when a field of the metadata struct could not be handled.
+type FieldError struct {
+	Field string // This is the name of the field.
+	Err   error  // This is the underlying error.
+}
+
+var _ error = (*FieldError)(nil)
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("could not handle field %q: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// handleField handles a single field of the metadata struct.
+//
+// The index is the full index sequence of the field within the metadata struct.
+func handleField(info *RestfulFunctionInfo, field reflect.StructField, index []int) error {
 	// "Anonymous" fields are when you embed a struct.
 	//
 	// When we have an anonymous field, go through all of *its* fields and add them.
 	if field.Anonymous {
 		for i := range field.Type.NumField() {
-			err := handleField(info, field.Type.Field(i))
+			err := handleField(info, field.Type.Field(i), append(slices.Clone(index), i))
 			if err != nil {
 				return err
 			}
@@ -125,7 +185,8 @@ func handleField(info *RestfulFunctionInfo, field reflect.StructField) error {
 	}
 
 	inputField := InputField{
-		Name: field.Name,
+		Name:  field.Name,
+		Index: index,
 	}
 
 	if apiTagKey == "-" {
@@ -147,5 +208,56 @@ func handleField(info *RestfulFunctionInfo, field reflect.StructField) error {
 		info.InputFields = append(info.InputFields, inputField)
 	}
 
+	if validateTagText, ok := field.Tag.Lookup("validate"); ok {
+		validation, err := parseValidation(validateTagText, field)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		validation.Index = index
+		validation.newError, err = info.newValidationErrorFunction(apiTagKey, field.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		validation.isGiven = info.newValidationGivenFunction(field)
+		info.Validations = append(info.Validations, validation)
+
+		if apiTagKey == "cookie" {
+			for cookieIndex := range info.CookieParameters {
+				if info.CookieParameters[cookieIndex].FieldName == field.Name {
+					info.CookieParameters[cookieIndex].Validation = validation
+				}
+			}
+		}
+	}
+
 	return nil
 }
+
+// apiTagOption is a single modifier from an API tag value.
+//
+// For example, in `api:"query:limit;required"`, "required" is an option with no value.
+type apiTagOption struct {
+	Key   string // This is the name of the option.
+	Value string // This is the value of the option, if any.
+}
+
+// splitAPITagValue splits an API tag value into its primary value and its options.
+//
+// The primary value is everything before the first ";"; each remaining ";"-separated part is an
+// option of the form "key" or "key:value".
+func splitAPITagValue(apiTagValue string) (string, []apiTagOption) {
+	tagParts := strings.Split(apiTagValue, ";")
+
+	var options []apiTagOption
+	for _, tagPart := range tagParts[1:] {
+		tagPartParts := strings.SplitN(tagPart, ":", 2)
+		option := apiTagOption{
+			Key: tagPartParts[0],
+		}
+		if len(tagPartParts) > 1 {
+			option.Value = tagPartParts[1]
+		}
+		options = append(options, option)
+	}
+	return tagParts[0], options
+}
@@ -1,13 +1,20 @@
 package restfulwrapper
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"iter"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/emicklei/go-restful/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -293,6 +300,18 @@ func TestParseRestfulFunction(t *testing.T) {
 				require.NotNil(t, output)
 				assert.Equal(t, []string{"application/x-www-form-urlencoded"}, output.Consumes)
 			})
+			t.Run("good struct form", func(t *testing.T) {
+				input := func(struct {
+					Body struct {
+						Name string `json:"name"`
+					} `api:"body:consumes:application/json,application/x-www-form-urlencoded,multipart/form-data"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"}, output.Consumes)
+			})
 			t.Run("bad values form", func(t *testing.T) {
 				input := func(struct {
 					Body string `api:"body:consumes:application/x-www-form-urlencoded"`
@@ -302,6 +321,382 @@ func TestParseRestfulFunction(t *testing.T) {
 				require.NotNil(t, err)
 				assert.Nil(t, output)
 			})
+			t.Run("maxbytes", func(t *testing.T) {
+				input := func(struct {
+					Body string `api:"body:maxbytes:1024"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, int64(1024), output.MaxBodyBytes)
+			})
+			t.Run("bad maxbytes", func(t *testing.T) {
+				input := func(struct {
+					Body string `api:"body:maxbytes:-1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("multipart reader", func(t *testing.T) {
+				input := func(struct {
+					Body *multipart.Reader `api:"body"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, []string{"multipart/form-data"}, output.Consumes)
+			})
+			t.Run("multipart parts", func(t *testing.T) {
+				input := func(struct {
+					Body iter.Seq2[*multipart.Part, error] `api:"body:consumes:multipart/mixed"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, []string{"multipart/mixed"}, output.Consumes)
+			})
+			t.Run("bad multipart reader", func(t *testing.T) {
+				input := func(struct {
+					Body *multipart.Reader `api:"body:consumes:application/json"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
+		t.Run("cookie", func(t *testing.T) {
+			t.Run("good cookie", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"cookie:session" description:"my description"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				assert.Equal(t, 1, len(output.InputFields))
+				if assert.Equal(t, 1, len(output.CookieParameters)) {
+					assert.Equal(t, "Value1", output.CookieParameters[0].FieldName)
+					assert.Equal(t, "session", output.CookieParameters[0].Name)
+					assert.Equal(t, "my description", output.CookieParameters[0].Description)
+					assert.False(t, output.CookieParameters[0].Required)
+					assert.Equal(t, reflect.TypeOf(""), output.CookieParameters[0].Type)
+					assert.Nil(t, output.CookieParameters[0].Validation)
+				}
+			})
+			t.Run("validated cookie", func(t *testing.T) {
+				input := func(struct {
+					Value1 int `api:"cookie:size" validate:"min:1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				if assert.Equal(t, 1, len(output.CookieParameters)) {
+					assert.Equal(t, reflect.TypeOf(0), output.CookieParameters[0].Type)
+					if assert.NotNil(t, output.CookieParameters[0].Validation) {
+						assert.Equal(t, 1.0, *output.CookieParameters[0].Validation.Minimum)
+					}
+				}
+			})
+			t.Run("required cookie", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"cookie:session;required"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				if assert.Equal(t, 1, len(output.CookieParameters)) {
+					assert.True(t, output.CookieParameters[0].Required)
+				}
+			})
+			t.Run("duplicate cookie", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"cookie:session"`
+					Value2 string `api:"cookie:session"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("Bad cookie option", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"cookie:session;bogus"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
+		t.Run("file", func(t *testing.T) {
+			t.Run("good file", func(t *testing.T) {
+				input := func(struct {
+					Value1 *multipart.FileHeader   `api:"file:key1;required;maxsize:1024;types:image/png,text/*" description:"my description"`
+					Value2 []*multipart.FileHeader `api:"file:key2"`
+					Value3 io.ReadCloser           `api:"file:key3"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				assert.Equal(t, 3, len(output.InputFields))
+				assert.Equal(t, []string{"multipart/form-data"}, output.Consumes)
+				if assert.Equal(t, 3, len(output.FileParameters)) {
+					assert.Equal(t, "Value1", output.FileParameters[0].FieldName)
+					assert.Equal(t, "key1", output.FileParameters[0].Name)
+					assert.Equal(t, "my description", output.FileParameters[0].Description)
+					assert.True(t, output.FileParameters[0].Required)
+					assert.False(t, output.FileParameters[0].AllowMultiple)
+					assert.Equal(t, int64(1024), output.FileParameters[0].MaxSize)
+					assert.Equal(t, []string{"image/png", "text/*"}, output.FileParameters[0].ContentTypes)
+					assert.True(t, output.FileParameters[1].AllowMultiple)
+					assert.False(t, output.FileParameters[2].AllowMultiple)
+				}
+			})
+			t.Run("file with form", func(t *testing.T) {
+				input := func(struct {
+					Value1 string                `api:"form:key1"`
+					Value2 *multipart.FileHeader `api:"file:key2"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, []string{"multipart/form-data"}, output.Consumes)
+			})
+			t.Run("bad file type", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"file:key1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("bad file maxsize", func(t *testing.T) {
+				input := func(struct {
+					Value1 *multipart.FileHeader `api:"file:key1;maxsize:big"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("maxsize while reading", func(t *testing.T) {
+				type Metadata struct {
+					Value1 *multipart.FileHeader `api:"file:key1;maxsize:16"`
+				}
+				info, err := ParseRestfulFunction(func(meta Metadata) {})
+				require.Nil(t, err)
+
+				var body bytes.Buffer
+				multipartWriter := multipart.NewWriter(&body)
+				part, err := multipartWriter.CreateFormFile("key1", "a.txt")
+				require.Nil(t, err)
+				_, err = part.Write(bytes.Repeat([]byte("a"), 1024*1024))
+				require.Nil(t, err)
+				require.Nil(t, multipartWriter.Close())
+				bodySize := body.Len()
+
+				// Count how much of the body is read before the request fails.
+				bodyReader := &countingReader{Reader: &body}
+				httpRequest, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "/", bodyReader)
+				require.Nil(t, err)
+				httpRequest.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+
+				var meta Metadata
+				err = info.bindMetadata(restful.NewRequest(httpRequest), reflect.ValueOf(&meta).Elem())
+				var apiBodyError *APIBodyError
+				if assert.ErrorAs(t, err, &apiBodyError) {
+					assert.Equal(t, `key1: file "a.txt" is too large (maximum 16 bytes)`, apiBodyError.Error())
+				}
+				assert.Less(t, bodyReader.Count, bodySize/2)
+			})
+			t.Run("close file on error", func(t *testing.T) {
+				type Metadata struct {
+					Value1 io.ReadCloser `api:"file:key1"`
+					Value2 int           `api:"form:key2"`
+				}
+				info, err := ParseRestfulFunction(func(meta Metadata) {})
+				require.Nil(t, err)
+				info.MultipartMaxMemory = 1 // Make sure that the file is stored in a temporary file.
+
+				var body bytes.Buffer
+				multipartWriter := multipart.NewWriter(&body)
+				part, err := multipartWriter.CreateFormFile("key1", "a.txt")
+				require.Nil(t, err)
+				_, err = part.Write([]byte("hello"))
+				require.Nil(t, err)
+				require.Nil(t, multipartWriter.WriteField("key2", "bogus"))
+				require.Nil(t, multipartWriter.Close())
+
+				httpRequest, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "/", &body)
+				require.Nil(t, err)
+				httpRequest.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+				defer func() {
+					if httpRequest.MultipartForm != nil {
+						httpRequest.MultipartForm.RemoveAll()
+					}
+				}()
+
+				var meta Metadata
+				err = info.bindMetadata(restful.NewRequest(httpRequest), reflect.ValueOf(&meta).Elem())
+				require.NotNil(t, err)
+				require.NotNil(t, meta.Value1)
+				_, err = meta.Value1.Read(make([]byte, 1))
+				assert.ErrorIs(t, err, os.ErrClosed)
+			})
+			t.Run("file with body", func(t *testing.T) {
+				input := func(struct {
+					Body   multipart.Form        `api:"body"`
+					Value1 *multipart.FileHeader `api:"file:key1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
+		t.Run("form", func(t *testing.T) {
+			t.Run("good form", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"form:key1;required" description:"my description"`
+					Value2 []int  `api:"form:key2"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				assert.Equal(t, 2, len(output.InputFields))
+				assert.Equal(t, []string{"application/x-www-form-urlencoded", "multipart/form-data"}, output.Consumes)
+				if assert.Equal(t, 2, len(output.FormParameters)) {
+					assert.Equal(t, "Value1", output.FormParameters[0].FieldName)
+					assert.Equal(t, "key1", output.FormParameters[0].Name)
+					assert.Equal(t, "my description", output.FormParameters[0].Description)
+					assert.True(t, output.FormParameters[0].Required)
+					assert.False(t, output.FormParameters[0].AllowMultiple)
+					assert.Equal(t, "key2", output.FormParameters[1].Name)
+					assert.False(t, output.FormParameters[1].Required)
+					assert.True(t, output.FormParameters[1].AllowMultiple)
+				}
+			})
+			t.Run("duplicate form", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"form:key1"`
+					Value2 string `api:"form:key1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("form with body", func(t *testing.T) {
+				input := func(struct {
+					Value1 string     `api:"form:key1"`
+					Body   url.Values `api:"body"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("body with form", func(t *testing.T) {
+				input := func(struct {
+					Body   url.Values `api:"body"`
+					Value1 string     `api:"form:key1"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
+		t.Run("header", func(t *testing.T) {
+			t.Run("good header", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"header:X-Key1" description:"my description"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				assert.Equal(t, 1, len(output.InputFields))
+				if assert.Equal(t, 1, len(output.HeaderParameters)) {
+					assert.Equal(t, "Value1", output.HeaderParameters[0].FieldName)
+					assert.Equal(t, "X-Key1", output.HeaderParameters[0].Name)
+					assert.Equal(t, "my description", output.HeaderParameters[0].Description)
+					assert.False(t, output.HeaderParameters[0].Required)
+				}
+			})
+			t.Run("required header", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"header:X-Key1;required"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				if assert.Equal(t, 1, len(output.HeaderParameters)) {
+					assert.Equal(t, "X-Key1", output.HeaderParameters[0].Name)
+					assert.True(t, output.HeaderParameters[0].Required)
+				}
+			})
+			t.Run("optional header", func(t *testing.T) {
+				type Metadata struct {
+					Count   int           `api:"header:X-Count"`
+					Enabled bool          `api:"header:X-Enabled"`
+					Timeout time.Duration `api:"header:X-Timeout"`
+				}
+				info, err := ParseRestfulFunction(func(meta Metadata) {})
+				require.Nil(t, err)
+
+				rows := []struct {
+					Description string
+					Headers     map[string]string
+					Output      Metadata
+				}{
+					{Description: "absent", Output: Metadata{}},
+					{Description: "given", Headers: map[string]string{"X-Count": "3", "X-Enabled": "true", "X-Timeout": "5"}, Output: Metadata{Count: 3, Enabled: true, Timeout: 5}},
+				}
+				for rowIndex, row := range rows {
+					t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+						httpRequest, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+						require.Nil(t, err)
+						for key, value := range row.Headers {
+							httpRequest.Header.Set(key, value)
+						}
+
+						var meta Metadata
+						err = info.bindMetadata(restful.NewRequest(httpRequest), reflect.ValueOf(&meta).Elem())
+						require.Nil(t, err)
+						assert.Equal(t, row.Output, meta)
+					})
+				}
+			})
+			t.Run("Bad header option", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"header:X-Key1;required:yes"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
 		})
 		t.Run("httprequest", func(t *testing.T) {
 			t.Run("good httprequest", func(t *testing.T) {
@@ -368,6 +763,20 @@ func TestParseRestfulFunction(t *testing.T) {
 				}
 				assert.Equal(t, 0, len(output.QueryParameters))
 			})
+			t.Run("required path", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"path:key1;required"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				if assert.Equal(t, 1, len(output.PathParameters)) {
+					assert.Equal(t, "key1", output.PathParameters[0].Name)
+					assert.True(t, output.PathParameters[0].Required)
+				}
+			})
 			t.Run("Bad path", func(t *testing.T) {
 				input := func(struct {
 					Value1 string `api:"path"`
@@ -450,6 +859,33 @@ func TestParseRestfulFunction(t *testing.T) {
 				require.NotNil(t, err)
 				assert.Nil(t, output)
 			})
+			t.Run("required query", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"query:key1,oldKey;required" description:"my description"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+
+				assert.Equal(t, 1, len(output.InputFields))
+				if assert.Equal(t, 2, len(output.QueryParameters)) {
+					assert.Equal(t, "key1", output.QueryParameters[0].Name)
+					assert.True(t, output.QueryParameters[0].Required)
+
+					assert.Equal(t, "oldKey", output.QueryParameters[1].Name)
+					assert.False(t, output.QueryParameters[1].Required)
+				}
+			})
+			t.Run("Bad query option", func(t *testing.T) {
+				input := func(struct {
+					Value1 string `api:"query:key1;bogus"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
 			t.Run("Duplicate query", func(t *testing.T) {
 				input := func(struct {
 					Value1 string `api:"query:key1"`
@@ -461,6 +897,83 @@ func TestParseRestfulFunction(t *testing.T) {
 				assert.Nil(t, output)
 			})
 		})
+		t.Run("response header", func(t *testing.T) {
+			t.Run("good response header", func(t *testing.T) {
+				type Output struct {
+					Location string `json:"-" api:"header:location" description:"Where it is."`
+					Age      *int   `json:"-" api:"header:Age"`
+					Value    string `json:"value"`
+				}
+				input := func(struct{}) (*Output, error) { return nil, nil }
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				if assert.Equal(t, 2, len(output.ResponseHeaders)) {
+					assert.Equal(t, "Location", output.ResponseHeaders[0].Name)
+					assert.Equal(t, "Where it is.", output.ResponseHeaders[0].Description)
+					assert.Equal(t, "Age", output.ResponseHeaders[1].Name)
+				}
+			})
+			t.Run("Bad response header type", func(t *testing.T) {
+				type Output struct {
+					Location map[string]string `json:"-" api:"header:Location"`
+				}
+				input := func(struct{}) (Output, error) { return Output{}, nil }
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("Duplicate response header", func(t *testing.T) {
+				type Output struct {
+					A string `json:"-" api:"header:Location"`
+					B string `json:"-" api:"header:location"`
+				}
+				input := func(struct{}) (Output, error) { return Output{}, nil }
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+			t.Run("Other output tag", func(t *testing.T) {
+				type Output struct {
+					A string `json:"a" api:"query:a"`
+				}
+				input := func(struct{}) (Output, error) { return Output{}, nil }
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, 0, len(output.ResponseHeaders))
+			})
+			t.Run("Response header in the body", func(t *testing.T) {
+				type Output struct {
+					Location string `api:"header:Location"`
+				}
+				input := func(struct{}) (Output, error) { return Output{}, nil }
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
+		t.Run("status", func(t *testing.T) {
+			t.Run("good status", func(t *testing.T) {
+				input := func(struct {
+					_ string `api:"status:201"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.Nil(t, err)
+				require.NotNil(t, output)
+				assert.Equal(t, http.StatusCreated, output.SuccessStatus)
+			})
+			t.Run("Bad status", func(t *testing.T) {
+				input := func(struct {
+					_ string `api:"status:404"`
+				}) {
+				}
+				output, err := ParseRestfulFunction(input)
+				require.NotNil(t, err)
+				assert.Nil(t, output)
+			})
+		})
 		t.Run("Full example", func(t *testing.T) {
 			input := func(context.Context, struct {
 				PathValue1  string `api:"path:pathkey1" description:"my description"`
@@ -515,3 +1028,104 @@ func TestParseRestfulFunction(t *testing.T) {
 		})
 	})
 }
+
+func TestMatchContentType(t *testing.T) {
+	rows := []struct {
+		ContentType string
+		Allowed     []string
+		Output      bool
+	}{
+		{ContentType: "image/png", Allowed: []string{"image/png"}, Output: true},
+		{ContentType: "IMAGE/PNG", Allowed: []string{"image/png"}, Output: true},
+		{ContentType: "text/plain; charset=utf-8", Allowed: []string{"text/plain"}, Output: true},
+		{ContentType: "text/csv", Allowed: []string{"image/png", "text/*"}, Output: true},
+		{ContentType: "image/jpeg", Allowed: []string{"image/png"}, Output: false},
+		{ContentType: "textual/plain", Allowed: []string{"text/*"}, Output: false},
+		{ContentType: "", Allowed: []string{"text/*"}, Output: false},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.ContentType), func(t *testing.T) {
+			assert.Equal(t, row.Output, matchContentType(row.ContentType, row.Allowed))
+		})
+	}
+}
+
+func TestRequestMediaType(t *testing.T) {
+	rows := []struct {
+		Description string
+		ContentType string
+		Consumes    []string
+		Success     bool
+		Output      string
+	}{
+		{Description: "no consumes", ContentType: "text/plain", Consumes: nil, Success: true, Output: "text/plain"},
+		{Description: "no content type or consumes", ContentType: "", Consumes: nil, Success: true, Output: ""},
+		{Description: "no content type", ContentType: "", Consumes: []string{"application/json", "application/x-yaml"}, Success: true, Output: "application/json"},
+		{Description: "second consumes", ContentType: "application/x-yaml; charset=utf-8", Consumes: []string{"application/json", "application/x-yaml"}, Success: true, Output: "application/x-yaml"},
+		{Description: "wildcard", ContentType: "Application/Octet-Stream", Consumes: []string{"*/*"}, Success: true, Output: "application/octet-stream"},
+		{Description: "unsupported", ContentType: "text/plain", Consumes: []string{"application/json"}, Success: false},
+		{Description: "invalid", ContentType: "bogus/", Consumes: []string{"application/json"}, Success: false},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			httpRequest, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "/", nil)
+			require.Nil(t, err)
+			if row.ContentType != "" {
+				httpRequest.Header.Set("Content-Type", row.ContentType)
+			}
+
+			output, err := requestMediaType(restful.NewRequest(httpRequest), row.Consumes)
+			if !row.Success {
+				require.NotNil(t, err)
+
+				var apiResponseError *APIResponseError
+				if assert.ErrorAs(t, err, &apiResponseError) {
+					assert.Equal(t, http.StatusUnsupportedMediaType, apiResponseError.Code())
+				}
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.Output, output)
+		})
+	}
+}
+
+// countingReader counts the number of bytes read from the reader.
+type countingReader struct {
+	io.Reader
+	Count int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.Count += n
+	return n, err
+}
+
+func BenchmarkBindMetadata(b *testing.B) {
+	type Metadata struct {
+		ID     int      `api:"path:id"`
+		Limit  *int     `api:"query:limit,max" default:"10"`
+		Tags   []string `api:"query:tag"`
+		Tenant string   `api:"header:X-Tenant;required"`
+	}
+
+	info, err := ParseRestfulFunction(func(ctx context.Context, meta Metadata) error { return nil })
+	require.Nil(b, err)
+
+	httpRequest, err := http.NewRequestWithContext(b.Context(), http.MethodGet, "/items/5?max=20&tag=a&tag=b", nil)
+	require.Nil(b, err)
+	httpRequest.Header.Set("X-Tenant", "tenant")
+
+	req := restful.NewRequest(httpRequest)
+	req.PathParameters()["id"] = "5"
+
+	b.ReportAllocs()
+	for b.Loop() {
+		var meta Metadata
+		err := info.bindMetadata(req, reflect.ValueOf(&meta).Elem())
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package restfulwrapper
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"mime"
 	"net/http"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -19,20 +27,46 @@ type RestfulFunctionInfo struct {
 	OutErrorPosition    int           // This is the position of the error return value, if any.
 	OutResponsePosition int           // This is the position of the response return value, if any.
 
+	ReceiverType reflect.Type // This is the type of the API struct that the method belongs to; this is set by Register.
+	MethodName   string       // This is the name of the method on the API struct (or the full name of the function); this is set by Register and Handle.
+
 	HTTPMethod       string                           // This is the HTTP method.
 	HTTPPath         string                           // This is the path (including any "{}" router syntax).
 	Doc              string                           // Used with "restful".
 	Notes            string                           // Used with "restful".
+	CookieParameters []RestfulFunctionCookieParameter // Used with "restful".
+	FileParameters   []RestfulFunctionFileParameter   // Used with "restful".
+	FormParameters   []RestfulFunctionFormParameter   // Used with "restful".
 	PathParameters   []RestfulFunctionPathParameter   // Used with "restful".
 	QueryParameters  []RestfulFunctionQueryParameter  // Used with "restful".
 	HeaderParameters []RestfulFunctionHeaderParameter // Used with "restful".
+	ResponseHeaders  []RestfulFunctionResponseHeader  // Used with "restful".
 	BodyExample      any                              // Used with "restful".
 	ResponseExample  any                              // Used with "restful".
 	Do               []func(*restful.RouteBuilder)    // Used with "restful"; these will be called as "Do" functions.
 	Consumes         []string                         // Used with "restful".
 	Produces         []string                         // Used with "restful".
 
-	InputFields []InputField // This is the list of fields in the metadata struct and how we populate them.
+	SuccessStatus            int           // This is the status code of a successful response; 0 means "200 OK".  This is set by the "status" tag.
+	NoContent                bool          // If true (and there is no SuccessStatus), then a response without a value is "204 No Content".  This is set by the wrapper's NoContent.
+	ProblemDetails           bool          // If true, then errors are written as RFC 9457 Problem Details.  This is set by the wrapper's ProblemDetails.
+	BodyCodecContentTypes    []string      // These are the content types of the wrapper's body codecs that can decode the body; they are added to the route's Consumes.
+	ServerSentEventKeepAlive time.Duration // This is how often a keep-alive comment is sent on a Server-Sent Events stream; 0 means the default (15 seconds), and a negative value means never.  This is set by the wrapper's ServerSentEventKeepAlive.
+	BodyOptional             bool          // If true, then an empty request body is accepted.  This is set by the "body" tag's "empty" option.
+	MaxBodyBytes             int64         // This is the maximum size of the request body, in bytes; 0 means no limit.  This is set by the "body" tag or by the wrapper's MaxBodyBytes.
+	MultipartMaxMemory       int64         // This is the maximum number of bytes of a multipart form that are kept in memory (the rest are stored in temporary files); 0 means the default (10MB).  This is set by the wrapper's MultipartMaxMemory.
+
+	InputFields []InputField                 // This is the list of fields in the metadata struct and how we populate them.
+	Validations []*RestfulFunctionValidation // This is the list of validation constraints on the fields in the metadata struct.
+
+	WebSocket bool // If true, then this is a WebSocket endpoint.  This is set by HTTPMethodWEBSOCKET.
+
+	outputStream      streamKind   // This is the kind of streaming output, if any.
+	outputStreamItem  reflect.Type // This is the type of the items of a streaming "iter.Seq" or channel output.
+	hasWebSocketField bool         // This is true if the metadata has a "websocket" field.
+
+	bodyUsesCodecs bool                   // This is true if the body can be decoded with the body codecs.
+	bodyCodecs     map[string]BodyDecoder // These are the body codecs that can decode the body; this is set by the wrapper.
 
 	LocalMap map[string]string // This is an arbitrary mapping that can be used to store information.
 }
@@ -40,17 +74,51 @@ type RestfulFunctionInfo struct {
 // InputField represents a field on the metadata struct.
 type InputField struct {
 	Name     string             // This is the name of the field.
+	Index    []int              // This is the index sequence of the field within the metadata struct (for use with `reflect.Value.FieldByIndex`).
 	Function InputFieldFunction // This is the function that we will call to set its value.
 }
 
 // InputFieldFunction sets the value of the field.
 type InputFieldFunction func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error
 
+// RestfulFunctionCookieParameter represents a cookie parameter.
+//
+// "restful" has no parameter kind for cookies, so everything that documents them comes from here.
+type RestfulFunctionCookieParameter struct {
+	FieldName   string
+	Name        string
+	Description string
+	Required    bool
+	Type        reflect.Type               // This is the type of the metadata field.
+	Validation  *RestfulFunctionValidation // This is the validation constraints on the metadata field, if any.
+}
+
+// RestfulFunctionFileParameter represents a file uploaded as part of a multipart form.
+type RestfulFunctionFileParameter struct {
+	FieldName     string
+	Name          string
+	Description   string
+	AllowMultiple bool
+	Required      bool
+	MaxSize       int64    // This is the maximum size of each file, in bytes; 0 means no limit.
+	ContentTypes  []string // This is the list of allowed content types (such as "image/png" or "image/*"); empty means any.
+}
+
+// RestfulFunctionFormParameter represents a form parameter.
+type RestfulFunctionFormParameter struct {
+	FieldName     string
+	Name          string
+	Description   string
+	AllowMultiple bool
+	Required      bool
+}
+
 // RestfulFunctionPathParameter represents a path parameter.
 type RestfulFunctionPathParameter struct {
 	FieldName   string
 	Name        string
 	Description string
+	Required    bool
 }
 
 // RestfulFunctionQueryParameter represents a query parameter.
@@ -59,6 +127,7 @@ type RestfulFunctionQueryParameter struct {
 	Name          string
 	Description   string
 	AllowMultiple bool
+	Required      bool
 }
 
 // RestfulFunctionHeaderParameter represents a header parameter.
@@ -67,32 +136,80 @@ type RestfulFunctionHeaderParameter struct {
 	Name          string
 	Description   string
 	AllowMultiple bool
+	Required      bool
+}
+
+// RestfulFunctionResponseHeader represents a response header that is set from a field of the output struct.
+type RestfulFunctionResponseHeader struct {
+	FieldName   string
+	Name        string
+	Description string
+	Index       []int // This is the full index sequence of the field within the output struct.
+
+	formatter headerFormatter // This formats the field's value as header values.
 }
 
 // UpdateRouteBuilder updates a restful.Routebuilder with the information that we got from
 // parsing the function.
 func (info *RestfulFunctionInfo) UpdateRouteBuilder(routeBuilder *restful.RouteBuilder) {
+	if len(info.CookieParameters) > 0 {
+		// "restful" has no parameter kind for cookies, so they are left out of the route's parameters
+		// (they are documented by OpenAPI instead).
+		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
+	}
+	for _, fileParameter := range info.FileParameters {
+		parameter := restful.MultiPartFormParameter(fileParameter.Name, fileParameter.Description)
+		parameter.DataType("file")
+		parameter.Required(fileParameter.Required)
+		parameter.AllowMultiple(fileParameter.AllowMultiple)
+		routeBuilder.Param(parameter)
+		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
+	}
+	for _, formParameter := range info.FormParameters {
+		parameter := restful.FormParameter(formParameter.Name, formParameter.Description)
+		parameter.Required(formParameter.Required)
+		parameter.AllowMultiple(formParameter.AllowMultiple)
+		if formParameter.AllowMultiple {
+			parameter.CollectionFormat(restful.CollectionFormatMulti)
+		}
+		if validation := info.validationForField(formParameter.FieldName); validation != nil {
+			validation.UpdateParameter(parameter)
+		}
+		routeBuilder.Param(parameter)
+		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
+	}
 	for _, headerParameter := range info.HeaderParameters {
 		parameter := restful.HeaderParameter(headerParameter.Name, headerParameter.Description)
+		parameter.Required(headerParameter.Required)
 		parameter.AllowMultiple(headerParameter.AllowMultiple)
 		if headerParameter.AllowMultiple {
 			parameter.CollectionFormat(restful.CollectionFormatMulti)
 		}
+		if validation := info.validationForField(headerParameter.FieldName); validation != nil {
+			validation.UpdateParameter(parameter)
+		}
 		routeBuilder.Param(parameter)
 		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
 	}
 	for _, pathParameter := range info.PathParameters {
 		parameter := restful.PathParameter(pathParameter.Name, pathParameter.Description)
 		parameter.AllowEmptyValue(false)
+		if validation := info.validationForField(pathParameter.FieldName); validation != nil {
+			validation.UpdateParameter(parameter)
+		}
 		routeBuilder.Param(parameter)
 		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
 	}
 	for _, queryParameter := range info.QueryParameters {
 		parameter := restful.QueryParameter(queryParameter.Name, queryParameter.Description)
+		parameter.Required(queryParameter.Required)
 		parameter.AllowMultiple(queryParameter.AllowMultiple)
 		if queryParameter.AllowMultiple {
 			parameter.CollectionFormat(restful.CollectionFormatMulti)
 		}
+		if validation := info.validationForField(queryParameter.FieldName); validation != nil {
+			validation.UpdateParameter(parameter)
+		}
 		routeBuilder.Param(parameter)
 		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
 	}
@@ -104,14 +221,41 @@ func (info *RestfulFunctionInfo) UpdateRouteBuilder(routeBuilder *restful.RouteB
 		routeBuilder.Produces(info.Produces...)
 	}
 
+	if info.MaxBodyBytes > 0 {
+		routeBuilder.Returns(http.StatusRequestEntityTooLarge, "Request Entity Too Large", nil)
+	}
 	if info.BodyExample != nil {
 		// We have a body to read.
 		routeBuilder.Reads(info.BodyExample)
 		// And because we have a body to read, we can fail with a bad request.
 		routeBuilder.Returns(http.StatusBadRequest, "Bad Request", nil)
 	}
-	if info.ResponseExample != nil {
-		routeBuilder.Returns(http.StatusOK, "OK", info.ResponseExample)
+	if info.WebSocket {
+		routeBuilder.Returns(http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols), nil)
+		routeBuilder.Returns(http.StatusUpgradeRequired, http.StatusText(http.StatusUpgradeRequired), nil)
+	}
+	if info.NoContent && info.SuccessStatus == 0 {
+		// A method without an output always has no content; one with a pointer output has no content when it is nil.
+		if info.OutResponsePosition < 0 || info.FunctionValue.Type().Out(info.OutResponsePosition).Kind() == reflect.Pointer {
+			routeBuilder.Returns(http.StatusNoContent, http.StatusText(http.StatusNoContent), nil)
+		}
+	}
+	if info.ResponseExample != nil || info.SuccessStatus != 0 {
+		successStatus := info.successStatus()
+		var responseExample any
+		if bodyAllowedForStatus(successStatus) {
+			responseExample = info.ResponseExample
+		}
+		var headers map[string]restful.Header
+		for _, responseHeader := range info.ResponseHeaders {
+			if headers == nil {
+				headers = map[string]restful.Header{}
+			}
+			headers[responseHeader.Name] = restful.Header{
+				Description: responseHeader.Description,
+			}
+		}
+		routeBuilder.ReturnsWithHeaders(successStatus, http.StatusText(successStatus), responseExample, headers)
 	}
 
 	routeBuilder.Doc(info.Doc)
@@ -126,6 +270,10 @@ func (info *RestfulFunctionInfo) CreateFunctionWithError(errorHandler ErrorHandl
 	functionWithError := func(req *restful.Request, resp *restful.Response) error {
 		ctx := req.Request.Context()
 
+		if err := info.limitBody(req, resp); err != nil {
+			return applyErrorHandler(errorHandler, err)
+		}
+
 		// Create the list of arguments to pass to the method.
 		methodArguments := make([]reflect.Value, info.FunctionValue.Type().NumIn())
 
@@ -139,33 +287,33 @@ func (info *RestfulFunctionInfo) CreateFunctionWithError(errorHandler ErrorHandl
 		if info.InMetadataPosition >= 0 {
 			inputValue := reflect.New(info.FunctionValue.Type().In(info.InMetadataPosition)).Elem()
 
-			argumentType := info.FunctionValue.Type().In(info.InMetadataPosition)
-			switch argumentType.Kind() {
-			case reflect.Pointer:
-				argumentType = argumentType.Elem()
+			err := info.bindMetadata(req, inputValue)
+			if err != nil {
+				return applyErrorHandler(errorHandler, err)
 			}
 
-			if argumentType.Kind() != reflect.Struct {
-				return fmt.Errorf("unexpected input type: %v", argumentType.Kind())
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("Input: %+v", inputValue.Interface()))
 			}
+			methodArguments[info.InMetadataPosition] = inputValue
+		}
 
-			for _, inputField := range info.InputFields {
-				fieldValue := inputValue.FieldByName(inputField.Name)
-
-				err := inputField.Function(fieldValue, req, inputValue)
-				if err != nil {
-					if errorHandler != nil {
-						newErr := errorHandler(err)
-						if newErr != nil {
-							err = newErr
-						}
-					}
-					return err
-				}
+		// If this is a WebSocket endpoint, then upgrade the connection now that the request has been bound.
+		var webSocket *webSocketConn
+		if info.WebSocket {
+			var err error
+			webSocket, err = info.upgradeWebSocket(req, resp)
+			if err != nil {
+				return applyErrorHandler(errorHandler, err)
 			}
 
-			slog.DebugContext(ctx, fmt.Sprintf("Input: %+v", inputValue.Interface()))
-			methodArguments[info.InMetadataPosition] = inputValue
+			// If the method panics, then the connection still needs to be closed; the panic itself is recovered later.
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					webSocket.close(webSocketCloseInternalError, "")
+					panic(recovered)
+				}
+			}()
 		}
 
 		// Call the method.
@@ -184,33 +332,23 @@ func (info *RestfulFunctionInfo) CreateFunctionWithError(errorHandler ErrorHandl
 				err = methodResults[info.OutErrorPosition].Interface().(error)
 			}
 		}
+		// The connection has been upgraded, so there is no response to write.
+		if webSocket != nil {
+			webSocket.finish(ctx, err)
+			return nil
+		}
 		// If the method failed, then return that error.
 		if err != nil {
-			if errorHandler != nil {
-				newErr := errorHandler(err)
-				if newErr != nil {
-					err = newErr
-				}
-			}
-			return err
+			return applyErrorHandler(errorHandler, err)
 		}
 
-		// If we have a response output, then use that.
+		var output any
 		if info.OutResponsePosition >= 0 {
-			output := methodResults[info.OutResponsePosition].Interface()
-			if output == nil {
-				slog.DebugContext(ctx, "No output given; writing OK with nil.")
-				resp.WriteHeaderAndEntity(http.StatusOK, nil)
-			} else if writer, ok := output.(Writer); ok {
-				slog.DebugContext(ctx, "Custom output writer given; calling Write on it.")
-				writer.Write(resp)
-			} else {
-				slog.DebugContext(ctx, "Standard struct given; writing OK with it.")
-				resp.WriteHeaderAndEntity(http.StatusOK, output)
-			}
-		} else {
-			slog.DebugContext(ctx, "No output position configured; writing OK with nil.")
-			resp.WriteHeaderAndEntity(http.StatusOK, nil)
+			output = methodResults[info.OutResponsePosition].Interface()
+		}
+		err = info.writeOutput(req, resp, output)
+		if err != nil {
+			return applyErrorHandler(errorHandler, err)
 		}
 
 		return nil
@@ -218,3 +356,250 @@ func (info *RestfulFunctionInfo) CreateFunctionWithError(errorHandler ErrorHandl
 
 	return functionWithError
 }
+
+// limitBody limits the request body to the maximum body size, if any.
+//
+// If the request says that its body is larger than that, then this fails without reading it.
+func (info *RestfulFunctionInfo) limitBody(req *restful.Request, resp *restful.Response) error {
+	if info.MaxBodyBytes <= 0 || req.Request.Body == nil || req.Request.Body == http.NoBody {
+		return nil
+	}
+	if req.Request.ContentLength > info.MaxBodyBytes {
+		return newBodyTooLargeError(info.MaxBodyBytes)
+	}
+	req.Request.Body = http.MaxBytesReader(resp.ResponseWriter, req.Request.Body, info.MaxBodyBytes)
+	return nil
+}
+
+// newBodyTooLargeError returns the error for a request body that is larger than the limit.
+func newBodyTooLargeError(limit int64) error {
+	return NewAPIResponseError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is too large (maximum %d bytes).", limit))
+}
+
+// bindMetadata populates the fields of the metadata value from the request and then validates them.
+//
+// If the metadata value is a pointer, then a new struct will be allocated for it.
+func (info *RestfulFunctionInfo) bindMetadata(req *restful.Request, inputValue reflect.Value) error {
+	structValue := inputValue
+	if structValue.Kind() == reflect.Pointer {
+		structValue.Set(reflect.New(structValue.Type().Elem()))
+		structValue = structValue.Elem()
+	}
+
+	if structValue.Kind() != reflect.Struct {
+		return fmt.Errorf("unexpected input type: %v", structValue.Kind())
+	}
+
+	for _, inputField := range info.InputFields {
+		fieldValue := structValue.FieldByIndex(inputField.Index)
+
+		err := inputField.Function(fieldValue, req, structValue)
+		if err != nil {
+			info.closeFiles(structValue)
+			return err
+		}
+	}
+
+	// Now that all of the fields have been populated, make sure that they are valid.
+	for _, validation := range info.Validations {
+		if validation.isGiven != nil && !validation.isGiven(req) {
+			continue
+		}
+
+		fieldValue := structValue.FieldByIndex(validation.Index)
+
+		err := validation.Validate(fieldValue)
+		if err != nil {
+			info.closeFiles(structValue)
+			return validation.newError(err)
+		}
+	}
+
+	return nil
+}
+
+// writeOutput writes the output of the method to the response.
+//
+// The status code is the one from the "status" tag (200 OK by default), unless the output is a StatusCoder.
+//
+// Unless the output is a Writer, the content type of the response is negotiated from the request's "Accept"
+// header and the route's Produces; if nothing matches, then this returns a 406 error without writing anything.
+func (info *RestfulFunctionInfo) writeOutput(req *restful.Request, resp *restful.Response, output any) error {
+	ctx := req.Request.Context()
+
+	info.writeResponseHeaders(resp.Header(), output)
+
+	status := info.successStatus()
+	if statusCoder, ok := output.(StatusCoder); ok && !isNilOutput(output) {
+		// A nil pointer can still be a StatusCoder (if its type has a value receiver), but it cannot be called.
+		status = statusCoder.StatusCode()
+	} else if info.NoContent && info.SuccessStatus == 0 && isNilOutput(output) {
+		status = http.StatusNoContent
+	}
+
+	// If we have a response output, then use that.
+	if info.OutResponsePosition >= 0 {
+		if output == nil {
+			slog.DebugContext(ctx, "No output given; writing status with nil.")
+			return info.writeEntity(req, resp, status, nil)
+		} else if writer, ok := output.(Writer); ok {
+			slog.DebugContext(ctx, "Custom output writer given; calling Write on it.")
+			writer.Write(resp)
+			return nil
+		} else if info.outputStream != streamKindNone {
+			slog.DebugContext(ctx, "Streaming output given; streaming it.")
+			return info.writeStream(req, resp, status, output)
+		} else {
+			slog.DebugContext(ctx, "Standard struct given; writing status with it.")
+			return info.writeEntity(req, resp, status, output)
+		}
+	} else {
+		slog.DebugContext(ctx, "No output position configured; writing status with nil.")
+		return info.writeEntity(req, resp, status, nil)
+	}
+}
+
+// successStatus returns the status code of a successful response.
+func (info *RestfulFunctionInfo) successStatus() int {
+	if info.SuccessStatus == 0 {
+		return http.StatusOK
+	}
+	return info.SuccessStatus
+}
+
+// isNilOutput returns true if the output has no value (it is nil or a nil pointer).
+func isNilOutput(output any) bool {
+	if output == nil {
+		return true
+	}
+	outputValue := reflect.ValueOf(output)
+	return outputValue.Kind() == reflect.Pointer && outputValue.IsNil()
+}
+
+// bodyAllowedForStatus returns true if a response with the given status code may have a body.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// writeEntity writes the status and value to the response using the content type negotiated from the
+// request's "Accept" header and the route's Produces.
+//
+// If there is a registered response encoder for the content type, then that is used; otherwise, restful's
+// own entity writers are used.  If the route does not produce anything, then restful decides on its own.
+func (info *RestfulFunctionInfo) writeEntity(req *restful.Request, resp *restful.Response, status int, value any) error {
+	ctx := req.Request.Context()
+
+	if !bodyAllowedForStatus(status) {
+		resp.WriteHeader(status)
+		return nil
+	}
+
+	if len(info.Produces) == 0 {
+		resp.WriteHeaderAndEntity(status, value)
+		return nil
+	}
+
+	accept := req.Request.Header.Get("Accept")
+	contentType, ok := negotiateContentType(accept, info.Produces)
+	if !ok {
+		return NewAPIResponseError(http.StatusNotAcceptable, fmt.Sprintf("Unsupported Accept: %q.", accept))
+	}
+	if debugEnabled(ctx) {
+		slog.DebugContext(ctx, fmt.Sprintf("Response Content-Type: %s", contentType))
+	}
+
+	encoder, ok := registeredResponseEncoderMap[contentType]
+	if !ok {
+		// Let restful use its entity writer for the negotiated content type.
+		resp.SetRequestAccepts(contentType)
+		resp.WriteHeaderAndEntity(status, value)
+		return nil
+	}
+
+	resp.Header().Set("Content-Type", contentType)
+	resp.WriteHeader(status)
+	err := encoder(resp, value)
+	if err != nil {
+		// The status has already been written, so all that we can do is log the error.
+		slog.ErrorContext(ctx, fmt.Sprintf("Could not encode response (%s): %v", contentType, err))
+	}
+	return nil
+}
+
+// negotiateContentType returns the first content type that the route produces that is acceptable according to
+// the "Accept" header given, trying the most preferred media ranges first.
+//
+// An empty "Accept" header accepts everything.  This returns false if nothing is acceptable.
+func negotiateContentType(accept string, produces []string) (string, bool) {
+	type mediaRange struct {
+		Value   string
+		Quality float64
+	}
+
+	var mediaRanges []mediaRange
+	if strings.TrimSpace(accept) == "" {
+		mediaRanges = append(mediaRanges, mediaRange{Value: "*/*", Quality: 1})
+	}
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		mediaRanges = append(mediaRanges, mediaRange{Value: mediaType, Quality: quality})
+	}
+	slices.SortStableFunc(mediaRanges, func(a, b mediaRange) int {
+		return cmp.Compare(b.Quality, a.Quality)
+	})
+
+	for _, mediaRange := range mediaRanges {
+		for _, produce := range produces {
+			if matchContentType(produce, []string{mediaRange.Value}) {
+				mediaType, _, _ := mime.ParseMediaType(produce)
+				return mediaType, true
+			}
+		}
+	}
+	return "", false
+}
+
+// debugEnabled returns true if debug logging is enabled.
+//
+// This is used on the request path to avoid formatting debug messages that will never be logged.
+func debugEnabled(ctx context.Context) bool {
+	return slog.Default().Enabled(ctx, slog.LevelDebug)
+}
+
+// applyErrorHandler translates the error using the error handler, if there is one.
+//
+// Any error from reading past the maximum body size is first translated into a 413 error.
+func applyErrorHandler(errorHandler ErrorHandler, err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		err = newBodyTooLargeError(maxBytesError.Limit)
+	}
+	if errorHandler != nil {
+		newErr := errorHandler(err)
+		if newErr != nil {
+			err = newErr
+		}
+	}
+	return err
+}
@@ -1,11 +1,18 @@
 package restfulwrapper
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/emicklei/go-restful/v3"
@@ -14,13 +21,22 @@ import (
 func init() {
 	// body is used to set the body from a PATCH, POST, or PUT method.
 	//
+	// The body is decoded based on the request's Content-Type, which must be one of the content types
+	// that the route consumes.
+	//
 	// Additional fields:
 	// * consumes:${content-type}; this sets the content type that is expected.
 	// * empty; if true, empty bodies will be allowed.
+	// * maxbytes:${bytes}; this sets the maximum size of the body (overriding the wrapper's MaxBodyBytes).
 	//
 	// JSON is supported trivially using the "json" package, so you may use a full object here.
 	// YAML is supported as "application/x-yaml" using either a "string" or "[]byte" type.
 	// HTML forms are supported as "multipart/form-data" using either "multipart.Form" or "*multipart.Form".
+	// Any other struct may also be sent as a form (of either kind); each field is set from the form values with its
+	// JSON name, so the same struct can be sent as (for example) either JSON or a form.
+	// Any other content type can be supported by registering a decoder for it with RegisterBodyCodec.
+	// Multipart bodies can be streamed (without buffering them in memory or on disk) using either "*multipart.Reader"
+	// or "iter.Seq2[*multipart.Part, error]"; the parts must be consumed in order before the method returns.
 	Register("body", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
 		var consumes []string
 		allowEmpty := false
@@ -46,12 +62,23 @@ func init() {
 						return nil, fmt.Errorf("invalid body tag value for empty: %s", tagPartValue)
 					}
 					allowEmpty = true
+					info.BodyOptional = true
+				case "maxbytes":
+					value, err := strconv.ParseInt(tagPartValue, 10, 64)
+					if err != nil || value <= 0 {
+						return nil, fmt.Errorf("invalid body tag value for maxbytes: %s", tagPartValue)
+					}
+					info.MaxBodyBytes = value
 				default:
 					return nil, fmt.Errorf("invalid body tag: %s", tagPartKey)
 				}
 			}
 		}
 
+		if len(info.FileParameters) > 0 || len(info.FormParameters) > 0 {
+			return nil, fmt.Errorf("body tag cannot be used with file or form tags")
+		}
+
 		{
 			exampleValue := reflect.New(field.Type)
 			if exampleValue.Kind() == reflect.Pointer {
@@ -63,105 +90,193 @@ func init() {
 
 		// If the field type is one of the special ones that we know how to support, then
 		// handle them appropriately (set the content type).
-		switch field.Type.String() {
-		case "url.Values", "*url.Values":
+		switch field.Type {
+		case urlValuesType, reflect.PointerTo(urlValuesType):
 			if len(info.Consumes) == 0 {
 				info.Consumes = append(info.Consumes, "application/x-www-form-urlencoded")
 			}
-		case "multipart.Form", "*multipart.Form":
+		case multipartFormType, reflect.PointerTo(multipartFormType), multipartReaderPointerType, multipartPartsType:
 			if len(info.Consumes) == 0 {
 				info.Consumes = append(info.Consumes, "multipart/form-data")
 			}
+		case byteSliceType:
 		default:
-			// Don't do anything special; we'll use "ReadEntity" later.
+			// Don't do anything special; we'll use a registered body codec or "ReadEntity" later.
+			if field.Type.Kind() != reflect.String && len(info.Consumes) == 0 {
+				info.bodyUsesCodecs = true
+			}
+		}
+
+		// Any other struct can also be decoded from form data (of either kind), so that the same body can be sent as
+		// (for example) JSON or a form.
+		var decodeForm formDecoder
+		{
+			structType := field.Type
+			if structType.Kind() == reflect.Pointer {
+				structType = structType.Elem()
+			}
+			if structType.Kind() == reflect.Struct && structType != multipartFormType {
+				decodeForm = newFormDecoder(structType)
+			}
 		}
 
 		// If the content type is "application/x-www-form-urlencoded", then fail if the field type is incorrect.
 		if slices.Contains(info.Consumes, "application/x-www-form-urlencoded") {
-			switch field.Type.String() {
-			case "url.Values":
-			case "*url.Values":
+			switch field.Type {
+			case urlValuesType:
+			case reflect.PointerTo(urlValuesType):
 			default:
-				return nil, fmt.Errorf("invalid type for content-type application/x-www-form-urlencoded: %s", field.Type.String())
+				if decodeForm == nil {
+					return nil, fmt.Errorf("invalid type for content-type application/x-www-form-urlencoded: %s", field.Type.String())
+				}
 			}
 		}
 		// If the content type is "multipart/form-data", then fail if the field type is incorrect.
 		if slices.Contains(info.Consumes, "multipart/form-data") {
-			switch field.Type.String() {
-			case "multipart.Form":
-			case "*multipart.Form":
+			switch field.Type {
+			case multipartFormType:
+			case reflect.PointerTo(multipartFormType):
+			case multipartReaderPointerType:
+			case multipartPartsType:
 			default:
-				return nil, fmt.Errorf("invalid type for content-type multipart/form-data: %s", field.Type.String())
+				if decodeForm == nil {
+					return nil, fmt.Errorf("invalid type for content-type multipart/form-data: %s", field.Type.String())
+				}
+			}
+		}
+		// If the field is a streaming multipart type, then fail if the content type is not multipart.
+		isMultipartStream := field.Type == multipartReaderPointerType || field.Type == multipartPartsType
+		if isMultipartStream {
+			for _, contentType := range info.Consumes {
+				if !strings.HasPrefix(contentType, "multipart/") {
+					return nil, fmt.Errorf("invalid content-type for type %s: %s", field.Type.String(), contentType)
+				}
 			}
 		}
 
+		isPointer := field.Type.Kind() == reflect.Pointer
+		isString := field.Type.Kind() == reflect.String
+		isByteSlice := field.Type == byteSliceType
+		isURLValues := field.Type == urlValuesType || field.Type == reflect.PointerTo(urlValuesType)
+		isMultipartForm := field.Type == multipartFormType || field.Type == reflect.PointerTo(multipartFormType)
+
 		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
-			v.Set(reflect.New(field.Type).Elem())
+			ctx := req.Request.Context()
 
-			contentType := ""
-			if len(info.Consumes) > 0 {
-				contentType = info.Consumes[0]
+			v.SetZero()
+
+			// The route may consume more than what the tag declared (for example, from the wrapper's Consumes).
+			consumes := info.Consumes
+			if route := req.SelectedRoute(); route != nil {
+				consumes = route.Consumes()
+			}
+			mediaType, err := requestMediaType(req, consumes)
+			if err != nil {
+				return err
+			}
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("Content-Type: %s", mediaType))
+			}
+
+			if isMultipartStream {
+				// This only reads the multipart boundary from the header; the parts are read as they are consumed.
+				multipartReader, err := req.Request.MultipartReader()
+				if err != nil {
+					return NewAPIBodyError(fmt.Errorf("could not get multipart reader: %w", err))
+				}
+
+				if field.Type == multipartPartsType {
+					v.Set(reflect.ValueOf(multipartParts(multipartReader)))
+				} else {
+					v.Set(reflect.ValueOf(multipartReader))
+				}
+				return nil
 			}
-			slog.DebugContext(req.Request.Context(), fmt.Sprintf("Content-Type: %s", contentType))
 
-			switch contentType {
-			case "application/x-www-form-urlencoded":
+			switch {
+			case isURLValues && mediaType == "application/x-www-form-urlencoded":
 				err := req.Request.ParseForm()
 				if err != nil {
 					return NewAPIBodyError(fmt.Errorf("could not parse form data: %w", err))
 				}
 
-				switch field.Type.String() {
-				case "url.Values":
-					v.Set(reflect.ValueOf(req.Request.PostForm))
-				case "*url.Values":
+				if isPointer {
 					v.Set(reflect.ValueOf(&req.Request.PostForm))
+				} else {
+					v.Set(reflect.ValueOf(req.Request.PostForm))
 				}
-			case "multipart/form-data":
+			case isMultipartForm && mediaType == "multipart/form-data":
 				multipartReader, err := req.Request.MultipartReader()
 				if err != nil {
 					return NewAPIBodyError(fmt.Errorf("could not get multipart reader: %w", err))
 				}
-				multipartForm, err := multipartReader.ReadForm(10 * 1000 * 1000 /*10MB in RAM*/)
+				multipartForm, err := multipartReader.ReadForm(info.multipartMaxMemory())
 				if err != nil {
 					return NewAPIBodyError(fmt.Errorf("could not read multipart form: %w", err))
 				}
 
-				switch field.Type.String() {
-				case "multipart.Form":
-					v.Set(reflect.ValueOf(*multipartForm))
-				case "*multipart.Form":
+				if isPointer {
 					v.Set(reflect.ValueOf(multipartForm))
+				} else {
+					v.Set(reflect.ValueOf(*multipartForm))
+				}
+			case decodeForm != nil && (mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"):
+				// This will parse either kind of form, and the values from both end up in "PostForm".
+				err := info.parseMultipartForm(req)
+				if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+					return NewAPIBodyError(fmt.Errorf("could not parse form data: %w", err))
+				}
+
+				structValue := v
+				if isPointer {
+					v.Set(reflect.New(v.Type().Elem()))
+					structValue = v.Elem()
+				}
+				err = decodeForm(req.Request.PostForm, structValue)
+				if err != nil {
+					return NewAPIBodyError(fmt.Errorf("could not read form data: %w", err))
 				}
 			default:
 				// If they asked for a string, then read the body as a string.
-				if v.Kind() == reflect.String {
+				if isString {
 					contents, err := io.ReadAll(req.Request.Body)
 					if err != nil {
 						return NewAPIBodyError(fmt.Errorf("could not read request body (string): %w", err))
 					}
-					v.Set(reflect.ValueOf(string(contents)))
+					v.SetString(string(contents))
 					return nil
 				}
 
-				slog.DebugContext(req.Request.Context(), fmt.Sprintf("Body type: %s", v.Type().String()))
+				if debugEnabled(ctx) {
+					slog.DebugContext(ctx, fmt.Sprintf("Body type: %s", v.Type().String()))
+				}
 				// If they asked for a byte slice, then read the body as a byte slice.
-				if v.Type().String() == "[]byte" || v.Type().String() == "[]uint8" {
+				if isByteSlice {
 					contents, err := io.ReadAll(req.Request.Body)
 					if err != nil {
 						return NewAPIBodyError(fmt.Errorf("could not read request body (byte slice): %w", err))
 					}
-					v.Set(reflect.ValueOf(contents))
+					v.SetBytes(contents)
 					return nil
 				}
 
-				// Otherwise, attempt to use restful's default method.
 				if allowEmpty && req.Request.ContentLength == 0 {
 					// Allow empty bodies.
 					return nil
 				}
 
-				err := req.ReadEntity(v.Addr().Interface())
+				// If there is a registered body codec for the content type, then use it.
+				if decoder, ok := info.bodyCodecs[mediaType]; ok {
+					err = decoder(req.Request.Body, v.Addr().Interface())
+					if err != nil {
+						return NewAPIBodyError(fmt.Errorf("could not read request body (%s): %w", mediaType, err))
+					}
+					return nil
+				}
+
+				// Otherwise, attempt to use restful's default method.
+
+				err = req.ReadEntity(v.Addr().Interface())
 				if err != nil {
 					return NewAPIBodyError(fmt.Errorf("could not read request body (entity): %w", err))
 				}
@@ -169,6 +284,56 @@ func init() {
 			return nil
 		}, nil
 	})
+	// cookie is used to set a value from a request cookie.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if the cookie is missing and there is no default.
+	Register("cookie", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
+		name, options := splitAPITagValue(apiTagValue)
+		if name == "" {
+			return nil, fmt.Errorf("missing tag value")
+		}
+		if slices.ContainsFunc(info.CookieParameters, func(item RestfulFunctionCookieParameter) bool { return item.Name == name }) {
+			return nil, fmt.Errorf("duplicate cookie tag")
+		}
+		parameterOptions, err := parseParameterOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		info.CookieParameters = append(info.CookieParameters, RestfulFunctionCookieParameter{
+			FieldName:   field.Name,
+			Name:        name,
+			Description: field.Tag.Get("description"),
+			Required:    parameterOptions.required,
+			Type:        field.Type,
+		})
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		setter := newStringSetter(field.Type)
+		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
+			ctx := req.Request.Context()
+
+			var stringValue string
+			cookie, err := req.Request.Cookie(name)
+			if err == nil {
+				stringValue = cookie.Value
+			} else if hasDefault {
+				stringValue = defaultValue
+			} else if parameterOptions.required {
+				return NewAPICookieParameterError(name, fmt.Errorf("missing required cookie parameter"))
+			} else {
+				return nil
+			}
+
+			err = setter(stringValue, v)
+			if err != nil {
+				return NewAPICookieParameterError(name, err)
+			}
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("cookie: %s: Parsed %q to %+v.", name, stringValue, v.Interface()))
+			}
+			return nil
+		}, nil
+	})
 	Register("doc", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
 		if apiTagValue != "" {
 			return nil, fmt.Errorf("unexpected tag value: %s", apiTagValue)
@@ -189,28 +354,276 @@ func init() {
 			return nil
 		}, nil
 	})
+	// file is used to set a value from a file uploaded as part of a "multipart/form-data" body.
+	//
+	// The field may be one of the following types:
+	// * *multipart.FileHeader; this is the first file with the name.
+	// * []*multipart.FileHeader; these are all of the files with the name.
+	// * io.ReadCloser; this is the opened first file with the name.  The method must close it.
+	//
+	// This cannot be combined with the "body" tag, since both need to read the body.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if there is no file with the name.
+	// * maxsize:${bytes}; if given, the request will fail as soon as any file is larger than this (while it is being read).
+	// * types:${content-type},...; if given, the request will fail if any file has a content type that is not listed.
+	//   A content type may end in "/*" to allow all of its subtypes (for example, "image/*").
+	Register("file", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
+		name, options := splitAPITagValue(apiTagValue)
+		if name == "" {
+			return nil, fmt.Errorf("missing tag value")
+		}
+		if info.BodyExample != nil {
+			return nil, fmt.Errorf("file tag cannot be used with a body tag")
+		}
+		if slices.ContainsFunc(info.FileParameters, func(item RestfulFunctionFileParameter) bool { return item.Name == name }) {
+			return nil, fmt.Errorf("duplicate file tag")
+		}
+		switch field.Type {
+		case fileHeaderPointerType, fileHeaderSliceType, readCloserType:
+		default:
+			return nil, fmt.Errorf("bad type: %s", field.Type.String())
+		}
+
+		var maxSize int64
+		var contentTypes []string
+		var otherOptions []apiTagOption
+		for _, option := range options {
+			switch option.Key {
+			case "maxsize":
+				value, err := strconv.ParseInt(option.Value, 10, 64)
+				if err != nil || value <= 0 {
+					return nil, fmt.Errorf("invalid tag value for maxsize: %s", option.Value)
+				}
+				maxSize = value
+			case "types":
+				for _, contentType := range strings.Split(option.Value, ",") {
+					contentType = strings.TrimSpace(contentType)
+					if contentType == "" {
+						return nil, fmt.Errorf("invalid tag value for types: %s", option.Value)
+					}
+					contentTypes = append(contentTypes, contentType)
+				}
+			default:
+				otherOptions = append(otherOptions, option)
+			}
+		}
+		parameterOptions, err := parseParameterOptions(otherOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		info.FileParameters = append(info.FileParameters, RestfulFunctionFileParameter{
+			FieldName:     field.Name,
+			Name:          name,
+			Description:   field.Tag.Get("description"),
+			AllowMultiple: field.Type == fileHeaderSliceType,
+			Required:      parameterOptions.required,
+			MaxSize:       maxSize,
+			ContentTypes:  contentTypes,
+		})
+		// Files can only be uploaded with a multipart form.
+		info.Consumes = slices.DeleteFunc(info.Consumes, func(item string) bool { return item == "application/x-www-form-urlencoded" })
+		if len(info.Consumes) == 0 {
+			info.Consumes = []string{"multipart/form-data"}
+		}
+
+		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
+			ctx := req.Request.Context()
+
+			err := info.parseMultipartForm(req)
+			if err != nil {
+				var apiBodyError *APIBodyError
+				if errors.As(err, &apiBodyError) {
+					return err
+				}
+				return NewAPIBodyError(fmt.Errorf("%s: could not parse multipart form: %w", name, err))
+			}
+
+			var fileHeaders []*multipart.FileHeader
+			if req.Request.MultipartForm != nil {
+				fileHeaders = req.Request.MultipartForm.File[name]
+			}
+			if len(fileHeaders) == 0 {
+				if parameterOptions.required {
+					return NewAPIBodyError(fmt.Errorf("%s: missing required file", name))
+				}
+				return nil
+			}
+
+			for _, fileHeader := range fileHeaders {
+				if maxSize > 0 && fileHeader.Size > maxSize {
+					return NewAPIBodyError(fmt.Errorf("%s: file %q is too large: %d bytes (maximum %d)", name, fileHeader.Filename, fileHeader.Size, maxSize))
+				}
+				if len(contentTypes) > 0 {
+					contentType := fileHeader.Header.Get("Content-Type")
+					if !matchContentType(contentType, contentTypes) {
+						return NewAPIBodyError(fmt.Errorf("%s: file %q has an unsupported content type: %q", name, fileHeader.Filename, contentType))
+					}
+				}
+			}
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("file: %s: Got %d file(s).", name, len(fileHeaders)))
+			}
+
+			switch field.Type {
+			case fileHeaderPointerType:
+				if len(fileHeaders) > 1 {
+					slog.WarnContext(ctx, fmt.Sprintf("Multiple files given for file parameter %s: %d", name, len(fileHeaders)))
+				}
+				v.Set(reflect.ValueOf(fileHeaders[0]))
+			case fileHeaderSliceType:
+				v.Set(reflect.ValueOf(fileHeaders))
+			case readCloserType:
+				if len(fileHeaders) > 1 {
+					slog.WarnContext(ctx, fmt.Sprintf("Multiple files given for file parameter %s: %d", name, len(fileHeaders)))
+				}
+				file, err := fileHeaders[0].Open()
+				if err != nil {
+					return NewAPIBodyError(fmt.Errorf("%s: could not open file %q: %w", name, fileHeaders[0].Filename, err))
+				}
+				v.Set(reflect.ValueOf(file))
+			}
+			return nil
+		}, nil
+	})
+	// form is used to set a value from a field of an "application/x-www-form-urlencoded" or
+	// "multipart/form-data" body.
+	//
+	// This cannot be combined with the "body" tag, since both need to read the body.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if the form field is missing and there is no default.
+	Register("form", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
+		name, options := splitAPITagValue(apiTagValue)
+		if name == "" {
+			return nil, fmt.Errorf("missing tag value")
+		}
+		if info.BodyExample != nil {
+			return nil, fmt.Errorf("form tag cannot be used with a body tag")
+		}
+		if slices.ContainsFunc(info.FormParameters, func(item RestfulFunctionFormParameter) bool { return item.Name == name }) {
+			return nil, fmt.Errorf("duplicate form tag")
+		}
+		parameterOptions, err := parseParameterOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		info.FormParameters = append(info.FormParameters, RestfulFunctionFormParameter{
+			FieldName:     field.Name,
+			Name:          name,
+			Description:   field.Tag.Get("description"),
+			AllowMultiple: field.Type.Kind() == reflect.Slice,
+			Required:      parameterOptions.required,
+		})
+		if len(info.Consumes) == 0 {
+			info.Consumes = []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+		}
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		isSlice := field.Type.Kind() == reflect.Slice
+		var setter stringSetter
+		if isSlice {
+			setter = newStringSetter(field.Type.Elem())
+		} else {
+			setter = newStringSetter(field.Type)
+		}
+		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
+			ctx := req.Request.Context()
+
+			// This will parse either kind of form (only once), and the values from both end up in "PostForm".
+			err := info.parseMultipartForm(req)
+			if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				var apiBodyError *APIBodyError
+				if errors.As(err, &apiBodyError) {
+					return err
+				}
+				return NewAPIFormParameterError(name, fmt.Errorf("could not parse form: %w", err))
+			}
+
+			stringValues := req.Request.PostForm[name]
+			if len(stringValues) == 0 && hasDefault {
+				stringValues = []string{defaultValue}
+			}
+			if len(stringValues) == 0 && parameterOptions.required {
+				return NewAPIFormParameterError(name, fmt.Errorf("missing required form parameter"))
+			}
+			if isSlice {
+				v.Set(reflect.MakeSlice(v.Type(), len(stringValues), len(stringValues)))
+
+				for stringValueIndex, stringValue := range stringValues {
+					sliceItem := v.Index(stringValueIndex)
+
+					err := setter(stringValue, sliceItem)
+					if err != nil {
+						return NewAPIFormParameterError(name, err)
+					}
+					if debugEnabled(ctx) {
+						slog.DebugContext(ctx, fmt.Sprintf("form: %s: Parsed %q to %+v.", name, stringValue, sliceItem.Interface()))
+					}
+				}
+			} else {
+				if len(stringValues) > 0 {
+					if len(stringValues) > 1 {
+						slog.WarnContext(ctx, fmt.Sprintf("Multiple values given for form parameter %s: %v", name, stringValues))
+					}
+
+					stringValue := stringValues[0]
+
+					err := setter(stringValue, v)
+					if err != nil {
+						return NewAPIFormParameterError(name, err)
+					}
+					if debugEnabled(ctx) {
+						slog.DebugContext(ctx, fmt.Sprintf("form: %s: Parsed %q to %+v.", name, stringValue, v.Interface()))
+					}
+				}
+			}
+			return nil
+		}, nil
+	})
+	// header is used to set a value from a request header.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if the header is missing.
 	Register("header", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
-		if apiTagValue == "" {
+		name, options := splitAPITagValue(apiTagValue)
+		if name == "" {
 			return nil, fmt.Errorf("missing tag value")
 		}
-		if slices.ContainsFunc(info.HeaderParameters, func(item RestfulFunctionHeaderParameter) bool { return item.Name == apiTagValue }) {
+		if slices.ContainsFunc(info.HeaderParameters, func(item RestfulFunctionHeaderParameter) bool { return item.Name == name }) {
 			return nil, fmt.Errorf("duplicate header tag")
 		}
+		parameterOptions, err := parseParameterOptions(options)
+		if err != nil {
+			return nil, err
+		}
 		info.HeaderParameters = append(info.HeaderParameters, RestfulFunctionHeaderParameter{
 			FieldName:   field.Name,
-			Name:        apiTagValue,
+			Name:        name,
 			Description: field.Tag.Get("description"),
+			Required:    parameterOptions.required,
 		})
+		canonicalName := http.CanonicalHeaderKey(name)
+		setter := newStringSetter(field.Type)
 		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
 			ctx := req.Request.Context()
 
-			stringValue := req.HeaderParameter(apiTagValue)
+			stringValues := req.Request.Header[canonicalName]
+			if len(stringValues) == 0 {
+				if parameterOptions.required {
+					return NewAPIHeaderParameterError(name, fmt.Errorf("missing required header parameter"))
+				}
+				return nil
+			}
+			stringValue := stringValues[0]
 
-			err := parseStringToSingleValue(stringValue, v.Addr().Interface())
+			err := setter(stringValue, v)
 			if err != nil {
-				return NewAPIHeaderParameterError(apiTagValue, err)
+				return NewAPIHeaderParameterError(name, err)
+			}
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("header: %s: Parsed %q to %+v.", name, stringValue, v.Interface()))
 			}
-			slog.DebugContext(ctx, fmt.Sprintf("header: %s: Parsed %q to %+v.", apiTagValue, stringValue, v.Interface()))
 			return nil
 		}, nil
 	})
@@ -226,6 +639,11 @@ func init() {
 		}
 
 		info.HTTPMethod = apiTagValue
+		if apiTagValue == "WEBSOCKET" {
+			// WebSocket connections are upgraded from GET requests.
+			info.HTTPMethod = http.MethodGet
+			info.WebSocket = true
+		}
 
 		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
 			if v.CanSet() {
@@ -292,28 +710,45 @@ func init() {
 			return nil
 		}, nil
 	})
+	// path is used to set a value from a path parameter.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if the path parameter is empty.
 	Register("path", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
-		if apiTagValue == "" {
+		name, options := splitAPITagValue(apiTagValue)
+		if name == "" {
 			return nil, fmt.Errorf("missing tag value")
 		}
-		if slices.ContainsFunc(info.PathParameters, func(item RestfulFunctionPathParameter) bool { return item.Name == apiTagValue }) {
+		if slices.ContainsFunc(info.PathParameters, func(item RestfulFunctionPathParameter) bool { return item.Name == name }) {
 			return nil, fmt.Errorf("duplicate path tag")
 		}
+		parameterOptions, err := parseParameterOptions(options)
+		if err != nil {
+			return nil, err
+		}
 		info.PathParameters = append(info.PathParameters, RestfulFunctionPathParameter{
 			FieldName:   field.Name,
-			Name:        apiTagValue,
+			Name:        name,
 			Description: field.Tag.Get("description"),
+			Required:    parameterOptions.required,
 		})
+		setter := newStringSetter(field.Type)
 		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
 			ctx := req.Request.Context()
 
-			stringValue := req.PathParameter(apiTagValue)
+			stringValue := req.PathParameter(name)
+
+			if parameterOptions.required && stringValue == "" {
+				return NewAPIPathParameterError(name, fmt.Errorf("missing required path parameter"))
+			}
 
-			err := parseStringToSingleValue(stringValue, v.Addr().Interface())
+			err := setter(stringValue, v)
 			if err != nil {
-				return NewAPIPathParameterError(apiTagValue, err)
+				return NewAPIPathParameterError(name, err)
+			}
+			if debugEnabled(ctx) {
+				slog.DebugContext(ctx, fmt.Sprintf("path: %s: Parsed %q to %+v.", name, stringValue, v.Interface()))
 			}
-			slog.DebugContext(ctx, fmt.Sprintf("path: %s: Parsed %q to %+v.", apiTagValue, stringValue, v.Interface()))
 			return nil
 		}, nil
 	})
@@ -338,11 +773,23 @@ func init() {
 		}, nil
 	})
 
+	// query is used to set a value from one or more query parameters.
+	//
+	// Multiple names may be given, separated by commas; the first is the primary name, and the rest are
+	// documented as deprecated aliases.
+	//
+	// Additional fields:
+	// * required; if given, the request will fail if the query parameter is missing and there is no default.
 	Register("query", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
-		if apiTagValue == "" {
+		namesValue, options := splitAPITagValue(apiTagValue)
+		if namesValue == "" {
 			return nil, fmt.Errorf("missing tag value")
 		}
-		names := strings.Split(apiTagValue, ",")
+		parameterOptions, err := parseParameterOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		names := strings.Split(namesValue, ",")
 		for i := range names {
 			names[i] = strings.TrimSpace(names[i])
 		}
@@ -357,6 +804,7 @@ func init() {
 			Name:          primaryName,
 			Description:   field.Tag.Get("description"),
 			AllowMultiple: field.Type.Kind() == reflect.Slice,
+			Required:      parameterOptions.required,
 		})
 		for _, name := range names[1:] {
 			info.QueryParameters = append(info.QueryParameters, RestfulFunctionQueryParameter{
@@ -366,45 +814,50 @@ func init() {
 				AllowMultiple: field.Type.Kind() == reflect.Slice,
 			})
 		}
+		defaultValue, hasDefault := field.Tag.Lookup("default")
+		isSlice := field.Type.Kind() == reflect.Slice
+		var setter stringSetter
+		if isSlice {
+			setter = newStringSetter(field.Type.Elem())
+		} else {
+			setter = newStringSetter(field.Type)
+		}
 		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
 			ctx := req.Request.Context()
 
+			query := req.Request.URL.Query()
+
 			var name string           // This is the name of the parameter that was used.
 			var stringValues []string // This is the list of values for the parameter.
 			// Figure out which parameter was used.
 			// (Stop once we find a matching parameter.)
 			for _, n := range names {
-				v := req.QueryParameters(n)
+				v := query[n]
 				if len(v) > 0 {
 					name = n
 					stringValues = v
 					break // Stop here; we matched.
 				}
 			}
-			if len(stringValues) == 0 {
-				if defaultValue, hasDefault := field.Tag.Lookup("default"); hasDefault {
-					stringValues = []string{defaultValue}
-				}
+			if len(stringValues) == 0 && hasDefault {
+				stringValues = []string{defaultValue}
+			}
+			if len(stringValues) == 0 && parameterOptions.required {
+				return NewAPIQueryParameterError(primaryName, fmt.Errorf("missing required query parameter"))
 			}
-			if v.Kind() == reflect.Slice {
+			if isSlice {
 				v.Set(reflect.MakeSlice(v.Type(), len(stringValues), len(stringValues)))
 
 				for stringValueIndex, stringValue := range stringValues {
 					sliceItem := v.Index(stringValueIndex)
 
-					var queryValue any
-					if sliceItem.Kind() == reflect.Pointer {
-						sliceItem.Set(reflect.New(sliceItem.Type().Elem()))
-						queryValue = sliceItem.Interface()
-					} else {
-						queryValue = sliceItem.Addr().Interface()
-					}
-
-					err := parseStringToSingleValue(stringValue, queryValue)
+					err := setter(stringValue, sliceItem)
 					if err != nil {
 						return NewAPIQueryParameterError(name, err)
 					}
-					slog.DebugContext(ctx, fmt.Sprintf("query: %s: Parsed %q to %+v.", name, stringValue, reflect.ValueOf(queryValue).Elem()))
+					if debugEnabled(ctx) {
+						slog.DebugContext(ctx, fmt.Sprintf("query: %s: Parsed %q to %+v.", name, stringValue, sliceItem.Interface()))
+					}
 				}
 			} else {
 				if len(stringValues) > 0 {
@@ -414,19 +867,13 @@ func init() {
 
 					stringValue := stringValues[0]
 
-					var queryValue any
-					if v.Kind() == reflect.Pointer {
-						v.Set(reflect.New(v.Type().Elem()))
-						queryValue = v.Interface()
-					} else {
-						queryValue = v.Addr().Interface()
-					}
-
-					err := parseStringToSingleValue(stringValue, queryValue)
+					err := setter(stringValue, v)
 					if err != nil {
 						return NewAPIQueryParameterError(name, err)
 					}
-					slog.DebugContext(ctx, fmt.Sprintf("query: %s: Parsed %q to %+v.", name, stringValue, reflect.ValueOf(queryValue).Elem()))
+					if debugEnabled(ctx) {
+						slog.DebugContext(ctx, fmt.Sprintf("query: %s: Parsed %q to %+v.", name, stringValue, v.Interface()))
+					}
 				}
 			}
 			return nil
@@ -450,4 +897,277 @@ func init() {
 			return nil
 		}, nil
 	})
+	// status is used to set the status code of a successful response (instead of "200 OK").
+	//
+	// The value must be a 2xx status code, such as "201" or "204".  A response with "204 No Content" has no body.
+	Register("status", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
+		if apiTagValue == "" {
+			return nil, fmt.Errorf("missing tag value")
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Int:
+		case reflect.String:
+		default:
+			return nil, fmt.Errorf("bad kind: %s", field.Type.Kind().String())
+		}
+
+		status, err := strconv.Atoi(apiTagValue)
+		if err != nil || status < 200 || status > 299 {
+			return nil, fmt.Errorf("invalid status tag value: %s", apiTagValue)
+		}
+		info.SuccessStatus = status
+
+		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
+			if v.CanSet() {
+				if v.Kind() == reflect.Int {
+					v.SetInt(int64(status))
+				} else {
+					v.SetString(apiTagValue)
+				}
+			}
+			return nil
+		}, nil
+	})
+	// websocket is used to set the WebSocket connection of an HTTPMethodWEBSOCKET endpoint.
+	//
+	// The field must be a "*WebSocket[T]"; it is connected once the request has been upgraded, right before
+	// the method is called.
+	Register("websocket", func(apiTagValue string, field reflect.StructField, info *RestfulFunctionInfo) (InputFieldFunction, error) {
+		if apiTagValue != "" {
+			return nil, fmt.Errorf("unexpected tag value: %s", apiTagValue)
+		}
+		if field.Type.Kind() != reflect.Pointer || !field.Type.Implements(webSocketBinderType) {
+			return nil, fmt.Errorf("bad type: %s", field.Type.String())
+		}
+		if info.hasWebSocketField {
+			return nil, fmt.Errorf("duplicate websocket tag")
+		}
+		info.hasWebSocketField = true
+
+		return func(v reflect.Value, req *restful.Request, metadataValue reflect.Value) error {
+			webSocketValue := reflect.New(field.Type.Elem())
+			v.Set(webSocketValue)
+			req.SetAttribute(webSocketAttribute, webSocketValue.Interface())
+			return nil
+		}, nil
+	})
+}
+
+// defaultMultipartMaxMemory is the default maximum number of bytes of a multipart form that will be kept in memory;
+// anything beyond that will be stored in temporary files.
+const defaultMultipartMaxMemory = 10 * 1000 * 1000 // 10MB in RAM.
+
+var (
+	byteSliceType              = reflect.TypeOf([]byte(nil))
+	fileHeaderPointerType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType        = reflect.TypeOf([]*multipart.FileHeader(nil))
+	multipartFormType          = reflect.TypeOf(multipart.Form{})
+	multipartPartsType         = reflect.TypeOf(iter.Seq2[*multipart.Part, error](nil))
+	multipartReaderPointerType = reflect.TypeOf((*multipart.Reader)(nil))
+	readCloserType             = reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
+	urlValuesType              = reflect.TypeOf(url.Values{})
+)
+
+// parameterOptions contains the options that are common to the parameter tags.
+type parameterOptions struct {
+	required bool // If true, the parameter must be given.
+}
+
+// parseParameterOptions parses the options that are common to the parameter tags (such as "header", "path", and "query").
+func parseParameterOptions(options []apiTagOption) (parameterOptions, error) {
+	var result parameterOptions
+	for _, option := range options {
+		switch option.Key {
+		case "required":
+			if option.Value != "" {
+				return result, fmt.Errorf("invalid tag value for required: %s", option.Value)
+			}
+			result.required = true
+		default:
+			return result, fmt.Errorf("invalid parameter tag: %s", option.Key)
+		}
+	}
+	return result, nil
+}
+
+// multipartParts returns an iterator over the parts of the multipart reader.
+//
+// The iteration stops after the first error.  Each part is closed when the next one is read.
+func multipartParts(multipartReader *multipart.Reader) iter.Seq2[*multipart.Part, error] {
+	return func(yield func(*multipart.Part, error) bool) {
+		for {
+			part, err := multipartReader.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, NewAPIBodyError(fmt.Errorf("could not read multipart part: %w", err)))
+				return
+			}
+			if !yield(part, nil) {
+				return
+			}
+		}
+	}
+}
+
+// multipartMaxMemory returns the maximum number of bytes of a multipart form that will be kept in memory.
+func (info *RestfulFunctionInfo) multipartMaxMemory() int64 {
+	if info.MultipartMaxMemory <= 0 {
+		return defaultMultipartMaxMemory
+	}
+	return info.MultipartMaxMemory
+}
+
+// parseMultipartForm parses the request's form (of either kind) with "http.Request.ParseMultipartForm".
+//
+// If any file parameter has a maximum size, then each file is checked while the body is being read, and this
+// fails as soon as one is too large (instead of after the whole body has been stored).
+func (info *RestfulFunctionInfo) parseMultipartForm(req *restful.Request) error {
+	request := req.Request
+	if request.MultipartForm != nil || !slices.ContainsFunc(info.FileParameters, func(item RestfulFunctionFileParameter) bool { return item.MaxSize > 0 }) {
+		return request.ParseMultipartForm(info.multipartMaxMemory())
+	}
+
+	contentType := request.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return request.ParseMultipartForm(info.multipartMaxMemory())
+	}
+
+	// Copy the parts into a new multipart body as they are read, stopping at any file that is too large.
+	body := request.Body
+	pipeReader, pipeWriter := io.Pipe()
+	multipartWriter := multipart.NewWriter(pipeWriter)
+	var copyErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		copyErr = info.copyMultipartParts(multipart.NewReader(body, params["boundary"]), multipartWriter)
+		pipeWriter.CloseWithError(copyErr)
+	}()
+
+	request.Body = pipeReader
+	request.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+	err = request.ParseMultipartForm(info.multipartMaxMemory())
+	request.Header.Set("Content-Type", contentType)
+	request.Body = body
+
+	// Stop the copy (if it is still going) and wait for it, so that nothing reads the body after this returns.
+	pipeReader.Close()
+	<-done
+
+	if err != nil && copyErr != nil {
+		return copyErr
+	}
+	return err
+}
+
+// copyMultipartParts copies every part from the reader to the writer, failing if a file is larger than the
+// maximum size of its file parameter.
+func (info *RestfulFunctionInfo) copyMultipartParts(multipartReader *multipart.Reader, multipartWriter *multipart.Writer) error {
+	for {
+		part, err := multipartReader.NextPart()
+		if err == io.EOF {
+			return multipartWriter.Close()
+		}
+		if err != nil {
+			return err
+		}
+
+		partWriter, err := multipartWriter.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+
+		var maxSize int64
+		if part.FileName() != "" {
+			index := slices.IndexFunc(info.FileParameters, func(item RestfulFunctionFileParameter) bool { return item.Name == part.FormName() })
+			if index >= 0 {
+				maxSize = info.FileParameters[index].MaxSize
+			}
+		}
+		if maxSize <= 0 {
+			_, err = io.Copy(partWriter, part)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Read at most one byte more than the maximum so that we know when the file is too large.
+		size, err := io.Copy(partWriter, io.LimitReader(part, maxSize+1))
+		if err != nil {
+			return err
+		}
+		if size > maxSize {
+			return NewAPIBodyError(fmt.Errorf("%s: file %q is too large (maximum %d bytes)", part.FormName(), part.FileName(), maxSize))
+		}
+	}
+}
+
+// closeFiles closes any files that were opened for the "file" fields of the metadata.
+//
+// This is used when the metadata could not be bound, since the method will never be called to close them.
+func (info *RestfulFunctionInfo) closeFiles(structValue reflect.Value) {
+	for _, inputField := range info.InputFields {
+		if !slices.ContainsFunc(info.FileParameters, func(item RestfulFunctionFileParameter) bool { return item.FieldName == inputField.Name }) {
+			continue
+		}
+		fieldValue := structValue.FieldByIndex(inputField.Index)
+		if fieldValue.Type() != readCloserType || fieldValue.IsNil() {
+			continue
+		}
+		fieldValue.Interface().(io.ReadCloser).Close()
+	}
+}
+
+// requestMediaType returns the media type of the request body (without any parameters).
+//
+// This must match one of the content types that the route consumes (if any); otherwise, this returns a 415 error.
+// If the request does not have a Content-Type, then the first content type that the route consumes is assumed.
+func requestMediaType(req *restful.Request, consumes []string) (string, error) {
+	contentType := req.Request.Header.Get("Content-Type")
+	if contentType == "" {
+		if len(consumes) == 0 {
+			return "", nil
+		}
+		contentType = consumes[0]
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", NewAPIResponseError(http.StatusUnsupportedMediaType, fmt.Sprintf("Invalid Content-Type: %q.", contentType))
+	}
+	if len(consumes) > 0 && !matchContentType(mediaType, consumes) {
+		return "", NewAPIResponseError(http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Content-Type: %q.", contentType))
+	}
+	return mediaType, nil
+}
+
+// matchContentType returns true if the content type matches any of the allowed content types.
+//
+// An allowed content type may be "*/*" to match everything or end in "/*" to match all of its subtypes.
+// Any parameters on the content types (such as "charset") are ignored.
+func matchContentType(contentType string, allowedContentTypes []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowedContentType := range allowedContentTypes {
+		allowedContentType, _, _ = strings.Cut(strings.ToLower(allowedContentType), ";")
+		allowedContentType = strings.TrimSpace(allowedContentType)
+		if allowedContentType == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowedContentType, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowedContentType {
+			return true
+		}
+	}
+	return false
 }
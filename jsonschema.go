@@ -0,0 +1,242 @@
+package restfulwrapper
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12, as used by OpenAPI 3.1).
+type JSONSchema map[string]any
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	parameterParserType = reflect.TypeOf((*ParameterParser)(nil)).Elem()
+)
+
+// jsonSchemaNameRegexp matches the characters that are not allowed in an OpenAPI component name.
+var jsonSchemaNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// jsonSchemaGenerator generates JSON Schemas from Go types.
+//
+// Named struct types are stored in `schemas` and referenced with "$ref" so that recursive types work
+// and so that each type is only described once.
+type jsonSchemaGenerator struct {
+	refPrefix string                // This is the prefix for "$ref" values, such as "#/components/schemas/".
+	schemas   map[string]JSONSchema // This is the map of schema names to their schemas.
+	names     map[reflect.Type]string
+}
+
+// newJSONSchemaGenerator returns a new schema generator whose references use the given prefix.
+func newJSONSchemaGenerator(refPrefix string) *jsonSchemaGenerator {
+	return &jsonSchemaGenerator{
+		refPrefix: refPrefix,
+		schemas:   map[string]JSONSchema{},
+		names:     map[reflect.Type]string{},
+	}
+}
+
+// Schema returns the schema for the given type.
+func (g *jsonSchemaGenerator) Schema(t reflect.Type) JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// Handle the special types that we know about.
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return JSONSchema{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return JSONSchema{"type": "integer"}
+	case reflect.TypeOf(json.RawMessage{}):
+		return JSONSchema{}
+	case reflect.TypeOf(url.Values{}):
+		return JSONSchema{"type": "object", "additionalProperties": JSONSchema{"type": "array", "items": JSONSchema{"type": "string"}}}
+	case reflect.TypeOf(multipart.Form{}):
+		return JSONSchema{"type": "object"}
+	case reflect.TypeOf(multipart.Reader{}), reflect.TypeOf(iter.Seq2[*multipart.Part, error](nil)):
+		return JSONSchema{"type": "object"}
+	case reflect.TypeOf(multipart.FileHeader{}):
+		return JSONSchema{"type": "string", "contentMediaType": "application/octet-stream"}
+	}
+
+	// Types that marshal themselves could be anything.
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return JSONSchema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return JSONSchema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema := JSONSchema{"type": "integer"}
+		switch t.Kind() {
+		case reflect.Int32:
+			schema["format"] = "int32"
+		case reflect.Int64:
+			schema["format"] = "int64"
+		}
+		return schema
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JSONSchema{"type": "integer", "minimum": 0}
+	case reflect.Float32:
+		return JSONSchema{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return JSONSchema{"type": "number", "format": "double"}
+	case reflect.String:
+		return JSONSchema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return JSONSchema{"type": "string", "contentEncoding": "base64"}
+		}
+		schema := JSONSchema{"type": "array", "items": g.Schema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			schema["minItems"] = t.Len()
+			schema["maxItems"] = t.Len()
+		}
+		return schema
+	case reflect.Map:
+		return JSONSchema{"type": "object", "additionalProperties": g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.uniqueName(t)
+			g.names[t] = name
+			g.schemas[name] = JSONSchema{} // Reserve the name in case the type is recursive.
+			g.schemas[name] = g.structSchema(t)
+		}
+		return JSONSchema{"$ref": g.refPrefix + name}
+	}
+
+	// Interfaces (and anything else) could be anything.
+	return JSONSchema{}
+}
+
+// ParameterSchema returns the schema for a parameter (such as a query parameter) of the given type.
+//
+// Parameters are parsed from strings, so any type that implements ParameterParser is a string.
+func (g *jsonSchemaGenerator) ParameterSchema(t reflect.Type) JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(parameterParserType) {
+		return JSONSchema{"type": "string"}
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		return JSONSchema{"type": "array", "items": g.ParameterSchema(t.Elem())}
+	}
+	return g.Schema(t)
+}
+
+// uniqueName returns a component name for the type that has not been used yet.
+func (g *jsonSchemaGenerator) uniqueName(t reflect.Type) string {
+	name := jsonSchemaNameRegexp.ReplaceAllString(t.Name(), "_")
+	if _, exists := g.schemas[name]; !exists {
+		return name
+	}
+
+	// Qualify the name with the package.
+	packageParts := strings.Split(t.PkgPath(), "/")
+	baseName := jsonSchemaNameRegexp.ReplaceAllString(packageParts[len(packageParts)-1]+"."+t.Name(), "_")
+	name = baseName
+	for i := 2; ; i++ {
+		if _, exists := g.schemas[name]; !exists {
+			return name
+		}
+		name = fmt.Sprintf("%s%d", baseName, i)
+	}
+}
+
+// structSchema returns the schema for the fields of a struct.
+func (g *jsonSchemaGenerator) structSchema(t reflect.Type) JSONSchema {
+	properties := map[string]any{}
+	var required []string
+	g.addStructProperties(t, properties, &required)
+
+	schema := JSONSchema{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructProperties adds the properties for the fields of a struct, following the rules of the
+// "encoding/json" package (including embedded structs, whose fields are shadowed by the outer fields).
+func (g *jsonSchemaGenerator) addStructProperties(t reflect.Type, properties map[string]any, required *[]string) {
+	embeddedProperties := map[string]any{}
+	var embeddedRequired []string
+	defer func() {
+		for name, schema := range embeddedProperties {
+			if _, exists := properties[name]; exists {
+				continue
+			}
+			properties[name] = schema
+			if slices.Contains(embeddedRequired, name) {
+				*required = append(*required, name)
+			}
+		}
+		slices.Sort(*required)
+	}()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(jsonTag, ",")
+		name := tagParts[0]
+		options := tagParts[1:]
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				g.addStructProperties(fieldType, embeddedProperties, &embeddedRequired)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		var schema JSONSchema
+		if slices.Contains(options, "string") {
+			schema = JSONSchema{"type": "string"}
+		} else {
+			schema = g.Schema(field.Type)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		properties[name] = schema
+
+		if !slices.Contains(options, "omitempty") && !slices.Contains(options, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,499 @@
+package restfulwrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// routeMetadataFunctionInfo is the route metadata key for the *RestfulFunctionInfo of a route created by Register.
+const routeMetadataFunctionInfo = "restfulwrapper.RestfulFunctionInfo"
+
+// OpenAPIVersion is the version of the OpenAPI specification that OpenAPI generates.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIDocument is an OpenAPI document.
+//
+// See: https://spec.openapis.org/oas/v3.1.0
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+// OpenAPIInfo contains the general information about the API.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem maps the (lowercase) HTTP methods of a path to their operations.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a single API operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+// OpenAPIParameter describes a single operation parameter.
+type OpenAPIParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Deprecated  bool       `json:"deprecated,omitempty"`
+	Schema      JSONSchema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes a request body.
+type OpenAPIRequestBody struct {
+	Description string                       `json:"description,omitempty"`
+	Required    bool                         `json:"required,omitempty"`
+	Content     map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType describes the content of a request or response for a particular media type.
+type OpenAPIMediaType struct {
+	Schema JSONSchema `json:"schema"`
+}
+
+// OpenAPIResponse describes a single response from an API operation.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIHeader describes a response header.
+type OpenAPIHeader struct {
+	Description string     `json:"description,omitempty"`
+	Schema      JSONSchema `json:"schema"`
+}
+
+// OpenAPIComponents holds the reusable objects of the document.
+type OpenAPIComponents struct {
+	Schemas map[string]JSONSchema `json:"schemas,omitempty"`
+}
+
+// JSON returns the document as indented JSON.
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML returns the document as YAML.
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, so decode it into a node (to keep the key order) and then re-encode it
+	// using the default YAML styles.
+	var node yaml.Node
+	err = yaml.Unmarshal(jsonBytes, &node)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert JSON to YAML: %w", err)
+	}
+	var clearStyle func(n *yaml.Node)
+	clearStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(&node)
+
+	return yaml.Marshal(&node)
+}
+
+// openAPIPath returns the path with any regular expressions removed from its path parameters.
+//
+// For example, "/items/{id:[0-9]{3}}" becomes "/items/{id}".  Braces are matched so that a regular
+// expression may contain its own braces.
+func openAPIPath(path string) string {
+	var builder strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			builder.WriteString(path)
+			return builder.String()
+		}
+		builder.WriteString(path[:start])
+
+		// Find the matching closing brace.
+		end := -1
+		depth := 0
+		for i := start; i < len(path) && end < 0; i++ {
+			switch path[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			// The braces are not balanced, so leave the rest alone.
+			builder.WriteString(path[start:])
+			return builder.String()
+		}
+
+		name, _, _ := strings.Cut(path[start+1:end], ":")
+		builder.WriteString("{" + strings.TrimSpace(name) + "}")
+		path = path[end+1:]
+	}
+}
+
+// OpenAPI returns an OpenAPI document describing every route that was added with Register.
+//
+// Routes that were added some other way (such as with Route) are not included.
+func (r *RestfulWrapper) OpenAPI(apiInfo OpenAPIInfo) *OpenAPIDocument {
+	generator := newJSONSchemaGenerator("#/components/schemas/")
+
+	document := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    apiInfo,
+		Paths:   map[string]OpenAPIPathItem{},
+	}
+
+	// The generic error output is used by every route.
+	generator.Schema(reflect.TypeOf(APIResponseErrorOutput{}))
+
+	for _, route := range r.ws.Routes() {
+		info, ok := route.Metadata[routeMetadataFunctionInfo].(*RestfulFunctionInfo)
+		if !ok {
+			continue
+		}
+
+		path := openAPIPath(route.Path)
+		if document.Paths[path] == nil {
+			document.Paths[path] = OpenAPIPathItem{}
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = openAPIOperation(generator, route, info)
+	}
+
+	document.Components.Schemas = generator.schemas
+	return document
+}
+
+// openAPIOperation returns the operation for the given route.
+func openAPIOperation(generator *jsonSchemaGenerator, route restful.Route, info *RestfulFunctionInfo) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		Summary:     route.Doc,
+		Description: route.Notes,
+		Responses:   map[string]*OpenAPIResponse{},
+		Deprecated:  route.Deprecated,
+	}
+
+	// "restful" has no parameter kind for cookies, so they are not in the route's parameters.
+	for _, cookieParameter := range info.CookieParameters {
+		schema := JSONSchema{"type": "string"}
+		if cookieParameter.Type != nil {
+			schema = generator.ParameterSchema(cookieParameter.Type)
+		}
+		if cookieParameter.Validation != nil {
+			cookieParameter.Validation.updateSchema(schema)
+		}
+
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        cookieParameter.Name,
+			In:          "cookie",
+			Description: cookieParameter.Description,
+			Required:    cookieParameter.Required,
+			Schema:      schema,
+		})
+	}
+
+	var formSchema JSONSchema // This is the schema for the form parameters, if any.
+	for _, parameter := range route.ParameterDocs {
+		data := parameter.Data()
+
+		var in string
+		switch data.Kind {
+		case restful.PathParameterKind:
+			in = "path"
+		case restful.QueryParameterKind:
+			in = "query"
+		case restful.HeaderParameterKind:
+			in = "header"
+		case restful.FormParameterKind, restful.MultiPartFormParameterKind:
+			if formSchema == nil {
+				formSchema = JSONSchema{"type": "object", "properties": map[string]any{}}
+			}
+			var schema JSONSchema
+			if data.Kind == restful.MultiPartFormParameterKind {
+				schema = info.openAPIFileSchema(data.Name)
+			} else if fieldType := info.parameterFieldType("form", data.Name); fieldType != nil {
+				schema = generator.ParameterSchema(fieldType)
+			} else {
+				schema = JSONSchema{"type": "string"}
+			}
+			applyOpenAPIParameterConstraints(schema, data)
+			if data.Description != "" {
+				schema["description"] = data.Description
+			}
+			formSchema["properties"].(map[string]any)[data.Name] = schema
+			if data.Required {
+				required, _ := formSchema["required"].([]string)
+				formSchema["required"] = append(required, data.Name)
+			}
+			continue // Form parameters are described by the request body.
+		default:
+			continue // Body parameters are described by the request body.
+		}
+
+		var schema JSONSchema
+		if fieldType := info.parameterFieldType(in, data.Name); fieldType != nil {
+			schema = generator.ParameterSchema(fieldType)
+		} else {
+			schema = JSONSchema{"type": "string"}
+		}
+		applyOpenAPIParameterConstraints(schema, data)
+
+		operation.Parameters = append(operation.Parameters, &OpenAPIParameter{
+			Name:        data.Name,
+			In:          in,
+			Description: data.Description,
+			Required:    data.Required || in == "path",
+			Deprecated:  in == "query" && info.isDeprecatedQueryParameter(data.Name),
+			Schema:      schema,
+		})
+	}
+
+	if formSchema != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  openAPIContent(route.Consumes, formSchema),
+		}
+	}
+	if route.ReadSample != nil {
+		schema := generator.Schema(reflect.TypeOf(route.ReadSample))
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: !info.BodyOptional,
+			Content:  openAPIContent(route.Consumes, schema),
+		}
+	}
+
+	hasSuccess := false
+	for code, responseError := range route.ResponseErrors {
+		response := &OpenAPIResponse{
+			Description: responseError.Message,
+		}
+		if response.Description == "" {
+			response.Description = http.StatusText(code)
+		}
+		if responseError.Model != nil {
+			response.Content = openAPIContent(route.Produces, generator.Schema(reflect.TypeOf(responseError.Model)))
+		} else if code >= 400 {
+			response.Content = openAPIErrorContent(generator, route, code, info)
+		}
+		for name, header := range responseError.Headers {
+			if response.Headers == nil {
+				response.Headers = map[string]*OpenAPIHeader{}
+			}
+			schema := JSONSchema{"type": "string"}
+			if header.Items != nil && header.Type != "" {
+				schema["type"] = header.Type
+			}
+			response.Headers[name] = &OpenAPIHeader{
+				Description: header.Description,
+				Schema:      schema,
+			}
+		}
+		operation.Responses[strconv.Itoa(code)] = response
+
+		if code < 300 {
+			hasSuccess = true
+		}
+	}
+	if !hasSuccess {
+		operation.Responses[strconv.Itoa(http.StatusOK)] = &OpenAPIResponse{
+			Description: http.StatusText(http.StatusOK),
+		}
+	}
+	if _, ok := operation.Responses[strconv.Itoa(http.StatusInternalServerError)]; !ok {
+		operation.Responses[strconv.Itoa(http.StatusInternalServerError)] = &OpenAPIResponse{
+			Description: http.StatusText(http.StatusInternalServerError),
+			Content:     openAPIErrorContent(generator, route, http.StatusInternalServerError, info),
+		}
+	}
+
+	return operation
+}
+
+// openAPIContent returns the content map for the given MIME types, all using the same schema.
+func openAPIContent(mimeTypes []string, schema JSONSchema) map[string]*OpenAPIMediaType {
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{restful.MIME_JSON}
+	}
+	content := map[string]*OpenAPIMediaType{}
+	for _, mimeType := range mimeTypes {
+		content[mimeType] = &OpenAPIMediaType{
+			Schema: schema,
+		}
+	}
+	return content
+}
+
+// openAPIErrorContent returns the content map for the built-in errors that the route can return with the given status code.
+//
+// If the route writes errors as Problem Details, then this is always "application/problem+json".
+func openAPIErrorContent(generator *jsonSchemaGenerator, route restful.Route, code int, info *RestfulFunctionInfo) map[string]*OpenAPIMediaType {
+	if info.ProblemDetails {
+		return openAPIContent([]string{MIME_PROBLEM_JSON}, generator.Schema(reflect.TypeOf(ProblemDetailsOutput{})))
+	}
+	return openAPIContent(route.Produces, openAPIErrorSchema(generator, code, info))
+}
+
+// openAPIErrorSchema returns the schema for the built-in errors that the route can return with the given status code.
+func openAPIErrorSchema(generator *jsonSchemaGenerator, code int, info *RestfulFunctionInfo) JSONSchema {
+	var schemas []any
+	if code == http.StatusBadRequest {
+		if len(info.CookieParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APICookieParameterErrorOutput{})))
+		}
+		if len(info.FormParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APIFormParameterErrorOutput{})))
+		}
+		if len(info.HeaderParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APIHeaderParameterErrorOutput{})))
+		}
+		if len(info.PathParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APIPathParameterErrorOutput{})))
+		}
+		if len(info.QueryParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APIQueryParameterErrorOutput{})))
+		}
+		if info.BodyExample != nil || len(info.FileParameters) > 0 {
+			schemas = append(schemas, generator.Schema(reflect.TypeOf(APIResponseErrorOutput{})))
+		}
+	}
+	switch len(schemas) {
+	case 0:
+		return generator.Schema(reflect.TypeOf(APIResponseErrorOutput{}))
+	case 1:
+		return schemas[0].(JSONSchema)
+	}
+	return JSONSchema{"oneOf": schemas}
+}
+
+// openAPIFileSchema returns the schema for the uploaded file with the given name.
+func (info *RestfulFunctionInfo) openAPIFileSchema(name string) JSONSchema {
+	schema := JSONSchema{"type": "string", "contentMediaType": "application/octet-stream"}
+
+	index := slices.IndexFunc(info.FileParameters, func(item RestfulFunctionFileParameter) bool { return item.Name == name })
+	if index < 0 {
+		return schema
+	}
+	fileParameter := info.FileParameters[index]
+	if len(fileParameter.ContentTypes) == 1 && !strings.HasSuffix(fileParameter.ContentTypes[0], "/*") {
+		schema["contentMediaType"] = fileParameter.ContentTypes[0]
+	}
+	if fileParameter.AllowMultiple {
+		return JSONSchema{"type": "array", "items": schema}
+	}
+	return schema
+}
+
+// applyOpenAPIParameterConstraints adds the documented parameter constraints to the schema.
+//
+// For arrays, the item constraints apply to the items.
+func applyOpenAPIParameterConstraints(schema JSONSchema, data restful.ParameterData) {
+	itemSchema := schema
+	if items, ok := schema["items"].(JSONSchema); ok && schema["type"] == "array" {
+		itemSchema = items
+	}
+
+	if data.Minimum != nil {
+		itemSchema["minimum"] = *data.Minimum
+	}
+	if data.Maximum != nil {
+		itemSchema["maximum"] = *data.Maximum
+	}
+	if data.MinLength != nil {
+		itemSchema["minLength"] = *data.MinLength
+	}
+	if data.MaxLength != nil {
+		itemSchema["maxLength"] = *data.MaxLength
+	}
+	if data.Pattern != "" {
+		itemSchema["pattern"] = data.Pattern
+	}
+	if len(data.PossibleValues) > 0 {
+		itemSchema["enum"] = data.PossibleValues
+	}
+	if data.MinItems != nil {
+		schema["minItems"] = *data.MinItems
+	}
+	if data.MaxItems != nil {
+		schema["maxItems"] = *data.MaxItems
+	}
+}
+
+// parameterFieldType returns the type of the metadata field for the given parameter, if any.
+func (info *RestfulFunctionInfo) parameterFieldType(in string, name string) reflect.Type {
+	var fieldName string
+	switch in {
+	case "path":
+		index := slices.IndexFunc(info.PathParameters, func(item RestfulFunctionPathParameter) bool { return item.Name == name })
+		if index >= 0 {
+			fieldName = info.PathParameters[index].FieldName
+		}
+	case "query":
+		index := slices.IndexFunc(info.QueryParameters, func(item RestfulFunctionQueryParameter) bool { return item.Name == name })
+		if index >= 0 {
+			fieldName = info.QueryParameters[index].FieldName
+		}
+	case "form":
+		index := slices.IndexFunc(info.FormParameters, func(item RestfulFunctionFormParameter) bool { return item.Name == name })
+		if index >= 0 {
+			fieldName = info.FormParameters[index].FieldName
+		}
+	case "header":
+		index := slices.IndexFunc(info.HeaderParameters, func(item RestfulFunctionHeaderParameter) bool { return item.Name == name })
+		if index >= 0 {
+			fieldName = info.HeaderParameters[index].FieldName
+		}
+	}
+	if fieldName == "" || info.InMetadataType == nil {
+		return nil
+	}
+
+	metadataType := info.InMetadataType
+	if metadataType.Kind() == reflect.Pointer {
+		metadataType = metadataType.Elem()
+	}
+	field, ok := metadataType.FieldByName(fieldName)
+	if !ok {
+		return nil
+	}
+	return field.Type
+}
+
+// isDeprecatedQueryParameter returns true if the query parameter is an alias for another query parameter.
+func (info *RestfulFunctionInfo) isDeprecatedQueryParameter(name string) bool {
+	index := slices.IndexFunc(info.QueryParameters, func(item RestfulFunctionQueryParameter) bool { return item.Name == name })
+	if index < 0 {
+		return false
+	}
+	fieldName := info.QueryParameters[index].FieldName
+	primaryIndex := slices.IndexFunc(info.QueryParameters, func(item RestfulFunctionQueryParameter) bool { return item.FieldName == fieldName })
+	return primaryIndex != index
+}
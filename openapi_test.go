@@ -0,0 +1,301 @@
+package restfulwrapper_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"mime/multipart"
+	"slices"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threatmate/restfulwrapper"
+	"gopkg.in/yaml.v3"
+)
+
+type OpenAPIAPI struct{}
+
+type OpenAPIWidget struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name" description:"The name of the widget."`
+	Tags     []string        `json:"tags,omitempty"`
+	Children []OpenAPIWidget `json:"children,omitempty"`
+}
+
+type OpenAPIGetWidgetMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_      string   `api:"httppath:/widgets/{id:[0-9]+}"`
+	_      string   `api:"doc" description:"Get a widget."`
+	_      string   `api:"notes" description:"Widget notes."`
+	ID     int      `api:"path:id" description:"The widget ID."`
+	Fields []string `api:"query:fields,field" description:"The fields to return." validate:"maxItems:5;enum:id,name"`
+	Tenant string   `api:"header:X-Tenant;required" description:"The tenant."`
+	Theme  string   `api:"cookie:theme" description:"The theme." validate:"enum:light,dark"`
+}
+
+func (a *OpenAPIAPI) GetWidget(ctx context.Context, meta OpenAPIGetWidgetMetadata) (*OpenAPIWidget, error) {
+	return nil, nil
+}
+
+type OpenAPIPostWidgetMetadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_    string        `api:"httppath:/widgets"`
+	_    string        `api:"doc" description:"Create a widget."`
+	Body OpenAPIWidget `api:"body"`
+}
+
+func (a *OpenAPIAPI) PostWidget(ctx context.Context, meta OpenAPIPostWidgetMetadata) error {
+	return nil
+}
+
+type OpenAPIPostWidgetFormMetadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_     string   `api:"httppath:/widgets/form"`
+	Name  string   `api:"form:name;required" description:"The name."`
+	Count int      `api:"form:count" validate:"min:1"`
+	Tags  []string `api:"form:tag"`
+}
+
+type OpenAPIPutWidgetMetadata struct {
+	restfulwrapper.HTTPMethodPUT
+	_    string         `api:"httppath:/widgets/{id:[0-9]+}"`
+	ID   int            `api:"path:id"`
+	Body *OpenAPIWidget `api:"body:empty"`
+}
+
+func (a *OpenAPIAPI) PutWidget(ctx context.Context, meta OpenAPIPutWidgetMetadata) error {
+	return nil
+}
+
+func (a *OpenAPIAPI) PostWidgetForm(ctx context.Context, meta OpenAPIPostWidgetFormMetadata) error {
+	return nil
+}
+
+type OpenAPIPostWidgetUploadMetadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_      string                  `api:"httppath:/widgets/upload"`
+	Image  *multipart.FileHeader   `api:"file:image;required;types:image/png"`
+	Extras []*multipart.FileHeader `api:"file:extra"`
+}
+
+func (a *OpenAPIAPI) PostWidgetUpload(ctx context.Context, meta OpenAPIPostWidgetUploadMetadata) error {
+	return nil
+}
+
+type OpenAPIGetWidgetCodeMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_    string `api:"httppath:/widgets/{id:[0-9]+}/codes/{code:[0-9]{3}}"`
+	ID   int    `api:"path:id"`
+	Code string `api:"path:code"`
+}
+
+type OpenAPIWidgetCode struct {
+	Code string `json:"code"`
+	ETag string `json:"-" api:"header:ETag" description:"The version of the code."`
+}
+
+func (a *OpenAPIAPI) GetWidgetCode(ctx context.Context, meta OpenAPIGetWidgetCodeMetadata) (*OpenAPIWidgetCode, error) {
+	return nil, nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	ctx := t.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().Register(ctx, "/v1", &OpenAPIAPI{})
+	webService.Route(webService.GET("/not-registered").RouteBuilder())
+
+	document := webService.OpenAPI(restfulwrapper.OpenAPIInfo{
+		Title:   "Test API",
+		Version: "1.0.0",
+	})
+	require.NotNil(t, document)
+	assert.Equal(t, "3.1.0", document.OpenAPI)
+	assert.Equal(t, "Test API", document.Info.Title)
+	assert.Equal(t, 5, len(document.Paths))
+	assert.NotContains(t, document.Paths, "/api/not-registered")
+
+	t.Run("GET", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets/{id}")
+		operation := document.Paths["/api/v1/widgets/{id}"]["get"]
+		require.NotNil(t, operation)
+		assert.Equal(t, "Get a widget.", operation.Summary)
+		assert.Equal(t, "Widget notes.", operation.Description)
+		assert.Nil(t, operation.RequestBody)
+
+		parameters := map[string]*restfulwrapper.OpenAPIParameter{}
+		for _, parameter := range operation.Parameters {
+			parameters[parameter.Name] = parameter
+		}
+		if assert.Contains(t, parameters, "id") {
+			assert.Equal(t, "path", parameters["id"].In)
+			assert.True(t, parameters["id"].Required)
+			assert.Equal(t, "integer", parameters["id"].Schema["type"])
+		}
+		if assert.Contains(t, parameters, "fields") {
+			assert.Equal(t, "query", parameters["fields"].In)
+			assert.False(t, parameters["fields"].Required)
+			assert.False(t, parameters["fields"].Deprecated)
+			assert.Equal(t, "array", parameters["fields"].Schema["type"])
+			assert.Equal(t, int64(5), parameters["fields"].Schema["maxItems"])
+			assert.Equal(t, []string{"id", "name"}, parameters["fields"].Schema["items"].(restfulwrapper.JSONSchema)["enum"])
+		}
+		if assert.Contains(t, parameters, "field") {
+			assert.True(t, parameters["field"].Deprecated)
+		}
+		if assert.Contains(t, parameters, "theme") {
+			assert.Equal(t, "cookie", parameters["theme"].In)
+			assert.Equal(t, "The theme.", parameters["theme"].Description)
+			assert.False(t, parameters["theme"].Required)
+			assert.Equal(t, restfulwrapper.JSONSchema{"type": "string", "enum": []string{"light", "dark"}}, parameters["theme"].Schema)
+		}
+		if assert.Contains(t, parameters, "X-Tenant") {
+			assert.Equal(t, "header", parameters["X-Tenant"].In)
+			assert.True(t, parameters["X-Tenant"].Required)
+		}
+
+		if assert.Contains(t, operation.Responses, "200") {
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/OpenAPIWidget"}, operation.Responses["200"].Content[restful.MIME_JSON].Schema)
+		}
+		if assert.Contains(t, operation.Responses, "400") {
+			assert.Contains(t, operation.Responses["400"].Content[restful.MIME_JSON].Schema, "oneOf")
+		}
+		assert.Contains(t, operation.Responses, "500")
+	})
+	t.Run("GET route parameters", func(t *testing.T) {
+		// Cookies cannot be described by the route's (Swagger) parameters, so they must only be in the OpenAPI document.
+		found := false
+		for _, route := range webService.WebService().Routes() {
+			if route.Method != "GET" || route.Path != "/api/v1/widgets/{id:[0-9]+}" {
+				continue
+			}
+			found = true
+			var names []string
+			for _, parameter := range route.ParameterDocs {
+				names = append(names, parameter.Data().Name)
+			}
+			assert.NotContains(t, names, "theme")
+			assert.Contains(t, names, "X-Tenant")
+		}
+		assert.True(t, found)
+	})
+	t.Run("GET with braces in a path parameter", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets/{id}/codes/{code}")
+		operation := document.Paths["/api/v1/widgets/{id}/codes/{code}"]["get"]
+		require.NotNil(t, operation)
+
+		var names []string
+		for _, parameter := range operation.Parameters {
+			names = append(names, parameter.Name)
+		}
+		assert.ElementsMatch(t, []string{"id", "code"}, names)
+
+		if assert.Contains(t, operation.Responses, "200") {
+			assert.Contains(t, operation.Responses["200"].Headers, "Etag")
+		}
+	})
+	t.Run("POST", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets")
+		operation := document.Paths["/api/v1/widgets"]["post"]
+		require.NotNil(t, operation)
+		if assert.NotNil(t, operation.RequestBody) {
+			assert.True(t, operation.RequestBody.Required)
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/OpenAPIWidget"}, operation.RequestBody.Content[restful.MIME_JSON].Schema)
+		}
+		if assert.Contains(t, operation.Responses, "400") {
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/APIResponseErrorOutput"}, operation.Responses["400"].Content[restful.MIME_JSON].Schema)
+		}
+	})
+	t.Run("PUT with an optional body", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets/{id}")
+		operation := document.Paths["/api/v1/widgets/{id}"]["put"]
+		require.NotNil(t, operation)
+		if assert.NotNil(t, operation.RequestBody) {
+			assert.False(t, operation.RequestBody.Required)
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/OpenAPIWidget"}, operation.RequestBody.Content[restful.MIME_JSON].Schema)
+		}
+	})
+	t.Run("POST form", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets/form")
+		operation := document.Paths["/api/v1/widgets/form"]["post"]
+		require.NotNil(t, operation)
+		assert.Equal(t, 0, len(operation.Parameters))
+		require.NotNil(t, operation.RequestBody)
+		require.Contains(t, operation.RequestBody.Content, "application/x-www-form-urlencoded")
+		require.Contains(t, operation.RequestBody.Content, "multipart/form-data")
+
+		schema := operation.RequestBody.Content["application/x-www-form-urlencoded"].Schema
+		assert.Equal(t, "object", schema["type"])
+		assert.Equal(t, []string{"name"}, schema["required"])
+		properties := schema["properties"].(map[string]any)
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "string", "description": "The name."}, properties["name"])
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "integer", "minimum": float64(1)}, properties["count"])
+		assert.Equal(t, "array", properties["tag"].(restfulwrapper.JSONSchema)["type"])
+
+		if assert.Contains(t, operation.Responses, "400") {
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/APIFormParameterErrorOutput"}, operation.Responses["400"].Content[restful.MIME_JSON].Schema)
+		}
+	})
+	t.Run("POST upload", func(t *testing.T) {
+		require.Contains(t, document.Paths, "/api/v1/widgets/upload")
+		operation := document.Paths["/api/v1/widgets/upload"]["post"]
+		require.NotNil(t, operation)
+		require.NotNil(t, operation.RequestBody)
+		require.Equal(t, 1, len(operation.RequestBody.Content))
+		require.Contains(t, operation.RequestBody.Content, "multipart/form-data")
+
+		schema := operation.RequestBody.Content["multipart/form-data"].Schema
+		assert.Equal(t, []string{"image"}, schema["required"])
+		properties := schema["properties"].(map[string]any)
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "string", "contentMediaType": "image/png"}, properties["image"])
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "array", "items": restfulwrapper.JSONSchema{"type": "string", "contentMediaType": "application/octet-stream"}}, properties["extra"])
+	})
+	t.Run("Components", func(t *testing.T) {
+		require.Contains(t, document.Components.Schemas, "OpenAPIWidget")
+		widget := document.Components.Schemas["OpenAPIWidget"]
+		assert.Equal(t, "object", widget["type"])
+		assert.Equal(t, []string{"id", "name"}, widget["required"])
+		properties := widget["properties"].(map[string]any)
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "string", "description": "The name of the widget."}, properties["name"])
+		assert.Equal(t, restfulwrapper.JSONSchema{"type": "array", "items": restfulwrapper.JSONSchema{"$ref": "#/components/schemas/OpenAPIWidget"}}, properties["children"])
+
+		require.Contains(t, document.Components.Schemas, "OpenAPIWidgetCode")
+		widgetCode := document.Components.Schemas["OpenAPIWidgetCode"]
+		assert.Equal(t, []string{"code"}, widgetCode["required"])
+		assert.Equal(t, []string{"code"}, slices.Collect(maps.Keys(widgetCode["properties"].(map[string]any))))
+
+		assert.Contains(t, document.Components.Schemas, "APIResponseErrorOutput")
+		assert.Contains(t, document.Components.Schemas, "APICookieParameterErrorOutput")
+		assert.Contains(t, document.Components.Schemas, "APIHeaderParameterErrorOutput")
+		assert.Contains(t, document.Components.Schemas, "APIPathParameterErrorOutput")
+		assert.Contains(t, document.Components.Schemas, "APIQueryParameterErrorOutput")
+
+		queryError := document.Components.Schemas["APIQueryParameterErrorOutput"]
+		assert.Equal(t, []string{"message", "parameter"}, queryError["required"])
+	})
+	t.Run("JSON", func(t *testing.T) {
+		contents, err := document.JSON()
+		require.Nil(t, err)
+
+		var output map[string]any
+		err = json.Unmarshal(contents, &output)
+		require.Nil(t, err)
+		assert.Equal(t, "3.1.0", output["openapi"])
+	})
+	t.Run("YAML", func(t *testing.T) {
+		contents, err := document.YAML()
+		require.Nil(t, err)
+
+		var output map[string]any
+		err = yaml.Unmarshal(contents, &output)
+		require.Nil(t, err)
+		assert.Equal(t, "3.1.0", output["openapi"])
+		paths := output["paths"].(map[string]any)
+		operation := paths["/api/v1/widgets/{id}"].(map[string]any)["get"].(map[string]any)
+		assert.Contains(t, operation["responses"], "200")
+	})
+}
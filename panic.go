@@ -0,0 +1,115 @@
+package restfulwrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// PanicError is the error that a panic in a route is converted into.
+//
+// It does not implement ErrorWriter, so it is written as a generic 500 error (unless the ErrorHandler translates it).
+type PanicError struct {
+	Value any    // This is the value that was passed to "panic".
+	Stack []byte // This is the stack trace of the goroutine that panicked.
+}
+
+var _ error = (*PanicError)(nil)
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicHook is called whenever a route panics, after the panic has been logged and before the error is written.
+//
+// This can be used to report panics to your own telemetry.  The info is nil for routes that were not added with
+// Register or Handle.
+type PanicHook func(ctx context.Context, info *RestfulFunctionInfo, err *PanicError)
+
+// recoverPanics returns a function that calls the given function, converting any panic into a *PanicError.
+//
+// The panic is logged (with its stack trace and the route's method and path), passed to the panic hook (if any),
+// and then translated by the error handler like any other error.  A panic with "http.ErrAbortHandler" is passed
+// along as-is, since that is how a handler asks "net/http" to abort the response.
+//
+// If the response has already started (such as a stream) or the connection has been taken over (such as a
+// WebSocket), then there is nowhere to write the error, so the panic is only logged and passed to the hook.
+func recoverPanics(f RestfulFunctionWithError, errorHandler ErrorHandler, panicHook PanicHook) RestfulFunctionWithError {
+	return func(req *restful.Request, resp *restful.Response) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// A panic from another goroutine (such as a Server-Sent Events iterator) is raised again as a *PanicError, with its own stack.
+			panicErr, ok := recovered.(*PanicError)
+			if !ok {
+				panicErr = &PanicError{
+					Value: recovered,
+					Stack: debug.Stack(),
+				}
+			}
+			if recoveredErr, ok := panicErr.Value.(error); ok && errors.Is(recoveredErr, http.ErrAbortHandler) {
+				panic(panicErr.Value)
+			}
+
+			ctx := req.Request.Context()
+
+			var info *RestfulFunctionInfo
+			if route := req.SelectedRoute(); route != nil {
+				info, _ = route.Metadata()[routeMetadataFunctionInfo].(*RestfulFunctionInfo)
+			}
+
+			if info != nil {
+				slog.ErrorContext(ctx, fmt.Sprintf("Panic in %s %s (%s): %v\n%s", info.HTTPMethod, info.HTTPPath, info.MethodName, panicErr.Value, panicErr.Stack))
+			} else {
+				slog.ErrorContext(ctx, fmt.Sprintf("Panic in %s %s: %v\n%s", req.Request.Method, req.SelectedRoutePath(), panicErr.Value, panicErr.Stack))
+			}
+
+			if panicHook != nil {
+				panicHook(ctx, info, panicErr)
+			}
+
+			if isResponseCommitted(req, resp) {
+				slog.ErrorContext(ctx, "The response has already started; not writing the panic.")
+				err = nil
+				return
+			}
+
+			err = applyErrorHandler(errorHandler, panicErr)
+		}()
+
+		return f(req, resp)
+	}
+}
+
+// responseCommittedAttribute is the request attribute that is set once a stream has started or a WebSocket
+// connection has been taken over.
+const responseCommittedAttribute = "restfulwrapper.responseCommitted"
+
+// markResponseCommitted marks the response as started, so that a later panic is not written onto it.
+func markResponseCommitted(req *restful.Request) {
+	req.SetAttribute(responseCommittedAttribute, true)
+}
+
+// isResponseCommitted returns true if the response has started, so an error can no longer be written to it.
+//
+// This is the case if the response was marked with markResponseCommitted, if anything was written to its body, or if
+// a status other than the default was written.
+func isResponseCommitted(req *restful.Request, resp *restful.Response) bool {
+	if committed, _ := req.Attribute(responseCommittedAttribute).(bool); committed {
+		return true
+	}
+	return resp.ContentLength() > 0 || resp.StatusCode() != http.StatusOK
+}
@@ -2,8 +2,10 @@ package restfulwrapper
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 // ParameterParser is an interface that a parameter can implement in order to
@@ -13,6 +15,11 @@ type ParameterParser interface {
 	ParseString(input string) error
 }
 
+// stringSetter parses a string value into the target value given.
+//
+// The target value must be settable.
+type stringSetter func(stringValue string, target reflect.Value) error
+
 // parseStringToSingleValue parses a string value into the target given.
 //
 // This will return an error if `target` is not a pointer or if it is nil.
@@ -24,10 +31,20 @@ func parseStringToSingleValue(stringValue string, target any) error {
 		return fmt.Errorf("invalid target: needed pointer, got %s", targetValue.Kind().String())
 	}
 
-	if targetValue.CanInterface() {
-		parser, ok := targetValue.Interface().(ParameterParser)
-		if ok {
-			err := parser.ParseString(stringValue)
+	return newStringSetter(targetValue.Elem().Type())(stringValue, targetValue.Elem())
+}
+
+// newStringSetter returns a stringSetter for the given type.
+//
+// All of the decisions about how to parse the string are made here, once, so that the
+// setter itself only has to do the parsing.
+//
+// If the type is a pointer, then the setter will allocate a new value for it.  If the type
+// cannot be parsed from a string, then the setter will always return an error.
+func newStringSetter(t reflect.Type) stringSetter {
+	if reflect.PointerTo(t).Implements(parameterParserType) {
+		return func(stringValue string, target reflect.Value) error {
+			err := target.Addr().Interface().(ParameterParser).ParseString(stringValue)
 			if err != nil {
 				return fmt.Errorf("could not parse string value: %w", err)
 			}
@@ -35,36 +52,143 @@ func parseStringToSingleValue(stringValue string, target any) error {
 		}
 	}
 
-	switch targetValue.Elem().Kind() {
+	switch t.Kind() {
 	case reflect.Bool:
-		v, err := strconv.ParseBool(stringValue)
-		if err != nil {
-			return err
+		return func(stringValue string, target reflect.Value) error {
+			v, err := strconv.ParseBool(stringValue)
+			if err != nil {
+				return err
+			}
+			target.SetBool(v)
+			return nil
 		}
-		targetValue.Elem().SetBool(v)
 	case reflect.Float32, reflect.Float64:
-		v, err := strconv.ParseFloat(stringValue, targetValue.Elem().Type().Bits())
-		if err != nil {
-			return err
+		bits := t.Bits()
+		return func(stringValue string, target reflect.Value) error {
+			v, err := strconv.ParseFloat(stringValue, bits)
+			if err != nil {
+				return err
+			}
+			target.SetFloat(v)
+			return nil
 		}
-		targetValue.Elem().SetFloat(v)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		v, err := strconv.ParseInt(stringValue, 10, targetValue.Elem().Type().Bits())
-		if err != nil {
-			return err
+		bits := t.Bits()
+		return func(stringValue string, target reflect.Value) error {
+			v, err := strconv.ParseInt(stringValue, 10, bits)
+			if err != nil {
+				return err
+			}
+			target.SetInt(v)
+			return nil
 		}
-		targetValue.Elem().SetInt(v)
 	case reflect.String:
-		targetValue.Elem().SetString(stringValue)
+		return func(stringValue string, target reflect.Value) error {
+			target.SetString(stringValue)
+			return nil
+		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		v, err := strconv.ParseUint(stringValue, 10, targetValue.Elem().Type().Bits())
-		if err != nil {
-			return err
+		bits := t.Bits()
+		return func(stringValue string, target reflect.Value) error {
+			v, err := strconv.ParseUint(stringValue, 10, bits)
+			if err != nil {
+				return err
+			}
+			target.SetUint(v)
+			return nil
+		}
+	case reflect.Pointer:
+		elemType := t.Elem()
+		elemSetter := newStringSetter(elemType)
+		return func(stringValue string, target reflect.Value) error {
+			elemValue := reflect.New(elemType)
+			err := elemSetter(stringValue, elemValue.Elem())
+			if err != nil {
+				return err
+			}
+			target.Set(elemValue)
+			return nil
 		}
-		targetValue.Elem().SetUint(v)
 	default:
-		return fmt.Errorf("could not parse to single value: unhandled kind: %s", targetValue.Elem().Kind().String())
+		err := fmt.Errorf("could not parse to single value: unhandled kind: %s", t.Kind().String())
+		return func(stringValue string, target reflect.Value) error {
+			return err
+		}
+	}
+}
+
+// formDecoder sets the fields of a struct from form values.
+type formDecoder func(values url.Values, structValue reflect.Value) error
+
+// newFormDecoder returns a formDecoder for the given struct type.
+//
+// Each exported field is set from the form values with its JSON name (from its "json" tag, or else its field name);
+// a field with a "json" tag of "-" is skipped, as is any field without any values.  A slice field gets every value,
+// and any other field gets the first one.
+func newFormDecoder(structType reflect.Type) formDecoder {
+	type formField struct {
+		name    string
+		index   []int
+		isSlice bool
+		setter  stringSetter
+	}
+
+	var formFields []formField
+	for _, field := range reflect.VisibleFields(structType) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if jsonTag, ok := field.Tag.Lookup("json"); ok {
+			jsonName, _, _ := strings.Cut(jsonTag, ",")
+			if jsonName == "-" {
+				continue
+			}
+			if jsonName != "" {
+				name = jsonName
+			}
+		}
+
+		formField := formField{
+			name:  name,
+			index: field.Index,
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type != byteSliceType {
+			formField.isSlice = true
+			formField.setter = newStringSetter(field.Type.Elem())
+		} else {
+			formField.setter = newStringSetter(field.Type)
+		}
+		formFields = append(formFields, formField)
 	}
 
-	return nil
+	return func(values url.Values, structValue reflect.Value) error {
+		for _, formField := range formFields {
+			stringValues := values[formField.name]
+			if len(stringValues) == 0 {
+				continue
+			}
+
+			fieldValue, err := structValue.FieldByIndexErr(formField.index)
+			if err != nil {
+				return fmt.Errorf("%s: %w", formField.name, err)
+			}
+			if !formField.isSlice {
+				err := formField.setter(stringValues[0], fieldValue)
+				if err != nil {
+					return fmt.Errorf("%s: %w", formField.name, err)
+				}
+				continue
+			}
+
+			fieldValue.Set(reflect.MakeSlice(fieldValue.Type(), len(stringValues), len(stringValues)))
+			for stringValueIndex, stringValue := range stringValues {
+				err := formField.setter(stringValue, fieldValue.Index(stringValueIndex))
+				if err != nil {
+					return fmt.Errorf("%s: %w", formField.name, err)
+				}
+			}
+		}
+		return nil
+	}
 }
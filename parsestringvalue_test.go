@@ -2,6 +2,7 @@ package restfulwrapper
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"testing"
 
@@ -202,3 +203,56 @@ func TestParseStringValue(t *testing.T) {
 		})
 	}
 }
+
+func TestFormDecoder(t *testing.T) {
+	type Embedded struct {
+		Note string `json:"note"`
+	}
+	type Target struct {
+		Embedded
+		Name    string   `json:"name,omitempty"`
+		Count   *int     `json:"count"`
+		Tags    []string `json:"tags"`
+		Enabled bool
+		Ignored string `json:"-"`
+		hidden  string
+	}
+
+	rows := []struct {
+		Description string
+		Values      url.Values
+		Success     bool
+		Output      Target
+	}{
+		{
+			Description: "empty",
+			Values:      url.Values{},
+			Success:     true,
+			Output:      Target{},
+		},
+		{
+			Description: "all fields",
+			Values:      url.Values{"name": {"a", "b"}, "count": {"2"}, "tags": {"x", "y"}, "Enabled": {"true"}, "note": {"n"}, "Ignored": {"i"}, "hidden": {"h"}},
+			Success:     true,
+			Output:      Target{Embedded: Embedded{Note: "n"}, Name: "a", Count: func() *int { v := 2; return &v }(), Tags: []string{"x", "y"}, Enabled: true},
+		},
+		{
+			Description: "bad value",
+			Values:      url.Values{"count": {"bogus"}},
+			Success:     false,
+		},
+	}
+	decoder := newFormDecoder(reflect.TypeOf(Target{}))
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			var output Target
+			err := decoder(row.Values, reflect.ValueOf(&output).Elem())
+			if !row.Success {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, row.Output, output)
+		})
+	}
+}
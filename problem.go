@@ -0,0 +1,57 @@
+package restfulwrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_PROBLEM_JSON is the content type for RFC 9457 Problem Details.
+const MIME_PROBLEM_JSON = "application/problem+json"
+
+// ProblemDetailsOutput is the output structure for an error in the RFC 9457 Problem Details format.
+//
+// The built-in errors do not define their own problem types, so "type" is always omitted (meaning "about:blank")
+// and "title" is the standard text for the status code.  Parameter errors add the "parameter" and "in" extension
+// members, where "in" is one of "cookie", "form", "header", "path", or "query"; body errors have an "in" of "body".
+// Hidden internal errors add the "errorId" extension member.
+type ProblemDetailsOutput struct {
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	In        string `json:"in,omitempty"`
+	ErrorID   string `json:"errorId,omitempty"`
+}
+
+// problemDetailer is implemented by the built-in errors so that they can be written as Problem Details.
+type problemDetailer interface {
+	problemDetails() ProblemDetailsOutput
+}
+
+// writeProblemDetails writes the Problem Details to the response.
+//
+// The title defaults to the standard text for the status code, and the instance defaults to the request's path.
+func writeProblemDetails(req *restful.Request, resp *restful.Response, output ProblemDetailsOutput) {
+	if output.Status == 0 {
+		output.Status = http.StatusInternalServerError
+	}
+	if output.Title == "" {
+		output.Title = http.StatusText(output.Status)
+	}
+	if output.Instance == "" {
+		output.Instance = req.Request.URL.Path
+	}
+
+	resp.Header().Set("Content-Type", MIME_PROBLEM_JSON)
+	resp.WriteHeader(output.Status)
+	err := json.NewEncoder(resp).Encode(output)
+	if err != nil {
+		slog.ErrorContext(req.Request.Context(), fmt.Sprintf("Could not write problem details: %v", err))
+	}
+}
@@ -0,0 +1,213 @@
+package restfulwrapper
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headerFormatter formats the given value as zero or more header values.
+type headerFormatter func(v reflect.Value) []string
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// parseResponseHeaders finds the response headers in the given output type.
+//
+// A response header is a field of the output struct with an `api:"header:${name}"` tag; the field's
+// value will be sent as that header instead of as part of the body.  The output is written as-is, so the field
+// must also be left out of the body with `json:"-"` (and `xml:"-"`, for XML); this also leaves it out of the
+// generated schema.  Other "api" tags are ignored.
+//
+// If the output type is not a struct (or a pointer to one), then it has no response headers.
+func parseResponseHeaders(outputType reflect.Type) ([]RestfulFunctionResponseHeader, error) {
+	if outputType.Kind() == reflect.Pointer {
+		outputType = outputType.Elem()
+	}
+	if outputType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var responseHeaders []RestfulFunctionResponseHeader
+	for fieldIndex := range outputType.NumField() {
+		field := outputType.Field(fieldIndex)
+		err := handleResponseField(&responseHeaders, field, field.Index)
+		if err != nil {
+			return nil, &FieldError{
+				Field: field.Name,
+				Err:   err,
+			}
+		}
+	}
+	return responseHeaders, nil
+}
+
+// handleResponseField handles a single field of the output struct.
+//
+// The index is the full index sequence of the field within the output struct.
+func handleResponseField(responseHeaders *[]RestfulFunctionResponseHeader, field reflect.StructField, index []int) error {
+	// Go through the fields of embedded structs, too.
+	if field.Anonymous && field.Type.Kind() == reflect.Struct {
+		for i := range field.Type.NumField() {
+			err := handleResponseField(responseHeaders, field.Type.Field(i), append(slices.Clone(index), i))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	apiTagText := field.Tag.Get("api")
+	if apiTagText == "" || apiTagText == "-" {
+		return nil
+	}
+
+	apiTagKey, apiTagValue, _ := strings.Cut(apiTagText, ":")
+	if apiTagKey != "header" {
+		// The output may use "api" tags for its own purposes.
+		return nil
+	}
+
+	name, options := splitAPITagValue(apiTagValue)
+	if name == "" {
+		return fmt.Errorf("missing header name")
+	}
+	if field.Tag.Get("json") != "-" {
+		return fmt.Errorf("response header field must also have `json:\"-\"`")
+	}
+	if len(options) > 0 {
+		return fmt.Errorf("unexpected header option: %s", options[0].Key)
+	}
+	name = http.CanonicalHeaderKey(name)
+	for _, responseHeader := range *responseHeaders {
+		if responseHeader.Name == name {
+			return fmt.Errorf("duplicate header: %s", name)
+		}
+	}
+
+	formatter, err := newHeaderFormatter(field.Type)
+	if err != nil {
+		return err
+	}
+
+	*responseHeaders = append(*responseHeaders, RestfulFunctionResponseHeader{
+		FieldName:   field.Name,
+		Name:        name,
+		Description: field.Tag.Get("description"),
+		Index:       index,
+		formatter:   formatter,
+	})
+	return nil
+}
+
+// newHeaderFormatter returns a headerFormatter for the given type.
+//
+// Times are formatted as HTTP dates, and slices have one header value per item.
+func newHeaderFormatter(t reflect.Type) (headerFormatter, error) {
+	var formatter func(v reflect.Value) string
+
+	switch {
+	case t == timeType:
+		formatter = func(v reflect.Value) string {
+			return v.Interface().(time.Time).UTC().Format(http.TimeFormat)
+		}
+	case t.Implements(stringerType):
+		formatter = func(v reflect.Value) string {
+			return v.Interface().(fmt.Stringer).String()
+		}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			formatter = func(v reflect.Value) string {
+				return strconv.FormatBool(v.Bool())
+			}
+		case reflect.Float32, reflect.Float64:
+			bits := t.Bits()
+			formatter = func(v reflect.Value) string {
+				return strconv.FormatFloat(v.Float(), 'f', -1, bits)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			formatter = func(v reflect.Value) string {
+				return strconv.FormatInt(v.Int(), 10)
+			}
+		case reflect.String:
+			formatter = func(v reflect.Value) string {
+				return v.String()
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			formatter = func(v reflect.Value) string {
+				return strconv.FormatUint(v.Uint(), 10)
+			}
+		case reflect.Pointer:
+			elemFormatter, err := newHeaderFormatter(t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			return func(v reflect.Value) []string {
+				if v.IsNil() {
+					return nil
+				}
+				return elemFormatter(v.Elem())
+			}, nil
+		case reflect.Slice:
+			elemFormatter, err := newHeaderFormatter(t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			return func(v reflect.Value) []string {
+				var values []string
+				for i := range v.Len() {
+					values = append(values, elemFormatter(v.Index(i))...)
+				}
+				return values
+			}, nil
+		default:
+			return nil, fmt.Errorf("unhandled header kind: %s", t.Kind().String())
+		}
+	}
+
+	return func(v reflect.Value) []string {
+		return []string{formatter(v)}
+	}, nil
+}
+
+// writeResponseHeaders sets the response headers from the fields of the output.
+//
+// Fields with zero values are skipped, so that their headers are not sent at all; use a pointer
+// to send a zero value.
+func (info *RestfulFunctionInfo) writeResponseHeaders(header http.Header, output any) {
+	if len(info.ResponseHeaders) == 0 {
+		return
+	}
+
+	outputValue := reflect.ValueOf(output)
+	if outputValue.Kind() == reflect.Pointer {
+		if outputValue.IsNil() {
+			return
+		}
+		outputValue = outputValue.Elem()
+	}
+	if outputValue.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, responseHeader := range info.ResponseHeaders {
+		fieldValue, err := outputValue.FieldByIndexErr(responseHeader.Index)
+		if err != nil {
+			// This can only happen with a nil embedded pointer, which we don't descend into anyway.
+			continue
+		}
+		if fieldValue.IsZero() {
+			continue
+		}
+		for _, value := range responseHeader.formatter(fieldValue) {
+			header.Add(responseHeader.Name, value)
+		}
+	}
+}
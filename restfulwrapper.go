@@ -2,12 +2,16 @@ package restfulwrapper
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 )
@@ -16,17 +20,35 @@ import (
 // a wrapper that makes it easy to add routes with common properties.
 func WebService(path string) *RestfulWrapper {
 	return &RestfulWrapper{
-		path: path,
-		ws:   new(restful.WebService).Path(path),
+		path:       path,
+		ws:         new(restful.WebService).Path(path),
+		bodyCodecs: maps.Clone(registeredBodyCodecMap),
 	}
 }
 
 // RestfulFunctionWithError is a restful.RouteFunction that returns an error.
 type RestfulFunctionWithError func(req *restful.Request, resp *restful.Response) error
 
+// ErrorIDHeader is the response header that holds the error ID of an internal error when internal errors are hidden.
+const ErrorIDHeader = "X-Error-Id"
+
+// internalErrorMessage is the message that replaces an internal error when internal errors are hidden.
+const internalErrorMessage = "An internal error occurred."
+
+// errorOptions controls how restfulFunctionWrapper writes errors.
+type errorOptions struct {
+	problemDetails     bool         // If true, then the built-in errors (and any error that does not implement ErrorWriter) are written as RFC 9457 Problem Details.
+	hideInternalErrors bool         // If true, then errors that do not implement ErrorWriter are replaced with an opaque message and an error ID.
+	errorHandler       ErrorHandler // This translates the error that a panic is converted into, if set.
+	panicHook          PanicHook    // This is called whenever the route panics, if set.
+}
+
 // restfulFunctionWrapper takes our more structured RestfulFunctionWithError function and returns
 // a function that restful can directly use.
-func restfulFunctionWrapper(f RestfulFunctionWithError) restful.RouteFunction {
+//
+// Any panic in the function is recovered and written as an error (see recoverPanics).
+func restfulFunctionWrapper(f RestfulFunctionWithError, options errorOptions) restful.RouteFunction {
+	f = recoverPanics(f, options.errorHandler, options.panicHook)
 	return func(req *restful.Request, resp *restful.Response) {
 		ctx := req.Request.Context()
 
@@ -38,6 +60,13 @@ func restfulFunctionWrapper(f RestfulFunctionWithError) restful.RouteFunction {
 			{
 				var errorWriter ErrorWriter
 				if errors.As(err, &errorWriter) {
+					if problemDetailer, ok := errorWriter.(problemDetailer); ok && options.problemDetails {
+						slog.InfoContext(ctx, "Error is a built-in error; writing problem details.")
+
+						writeProblemDetails(req, resp, problemDetailer.problemDetails())
+						return
+					}
+
 					slog.InfoContext(ctx, "Error is a pointer to an ErrorWriter; using its custom writer function.")
 
 					errorWriter.WriteError(resp)
@@ -47,9 +76,31 @@ func restfulFunctionWrapper(f RestfulFunctionWithError) restful.RouteFunction {
 
 			slog.InfoContext(ctx, "Error does not implement ErrorWriter; writing a generic error.")
 
+			// Unexpected errors may contain anything (SQL, file paths, etc.), so only the logs get the details.
+			var errorID string
+			errorType := fmt.Sprintf("%T", err)
+			message := err.Error()
+			if options.hideInternalErrors {
+				errorID = rand.Text()
+				errorType = ""
+				message = internalErrorMessage
+				slog.ErrorContext(ctx, fmt.Sprintf("Internal error (%s): [%T] %v", errorID, err, err), "errorId", errorID)
+				resp.Header().Set(ErrorIDHeader, errorID)
+			}
+
+			if options.problemDetails {
+				writeProblemDetails(req, resp, ProblemDetailsOutput{
+					Status:  http.StatusInternalServerError,
+					Detail:  message,
+					ErrorID: errorID,
+				})
+				return
+			}
+
 			output := APIResponseErrorOutput{
-				Type:    fmt.Sprintf("%T", err),
-				Message: err.Error(),
+				Type:    errorType,
+				Message: message,
+				ErrorID: errorID,
 			}
 			resp.WriteHeaderAndEntity(http.StatusInternalServerError, output)
 			return
@@ -62,14 +113,22 @@ type ContextAction func(ctx context.Context, info *RestfulFunctionInfo) context.
 
 // RestfulWrapper is our restful wrapper.
 type RestfulWrapper struct {
-	ws             *restful.WebService           // This is the WebService; we need this to create parameters.
-	path           string                        // This is the path that was initially provided.
-	attributes     map[string]any                // This is a list of any attributes to set for every request.
-	doFunctions    []func(*restful.RouteBuilder) // This is a list of any "do" functions.
-	consumes       []string                      // This is a list of any MIME types that will be consumed.
-	produces       []string                      // This is a list of any MIME types that will be produced.
-	contextActions []ContextAction               // This is a list of context actions to take for each request.
-	errorHandler   ErrorHandler                  // This is the error handler to use for each request.  If nil, the error will be returned as is.
+	ws              *restful.WebService           // This is the WebService; we need this to create parameters.
+	path            string                        // This is the path that was initially provided.
+	attributes      map[string]any                // This is a list of any attributes to set for every request.
+	doFunctions     []func(*restful.RouteBuilder) // This is a list of any "do" functions.
+	consumes        []string                      // This is a list of any MIME types that will be consumed.
+	produces        []string                      // This is a list of any MIME types that will be produced.
+	contextActions  []ContextAction               // This is a list of context actions to take for each request.
+	errorHandler    ErrorHandler                  // This is the error handler to use for each request.  If nil, the error will be returned as is.
+	maxBodyBytes    int64                         // This is the default maximum size of a request body, in bytes.  If 0, there is no limit.
+	multipartMemory int64                         // This is the maximum number of bytes of a multipart form that are kept in memory.  If 0, the default is used.
+	noContent       bool                          // If true, then responses without a value will be "204 No Content".
+	keepAlive       time.Duration                 // This is how often a keep-alive comment is sent on a Server-Sent Events stream.  If 0, the default is used.
+	problemDetails  bool                          // If true, then errors will be written as RFC 9457 Problem Details.
+	hideErrors      bool                          // If true, then internal errors will be hidden behind an error ID.
+	panicHook       PanicHook                     // This is called whenever a route panics, if set.
+	bodyCodecs      map[string]BodyDecoder        // This is the set of body codecs that were registered when the wrapper was created.
 }
 
 // Session returns a new session of the wrapper.  Any modifications will not affect
@@ -93,6 +152,14 @@ func (r *RestfulWrapper) Session() *RestfulWrapper {
 	newWrapper.produces = append(newWrapper.produces, r.produces...)
 	newWrapper.contextActions = append(newWrapper.contextActions, r.contextActions...)
 	newWrapper.errorHandler = r.errorHandler
+	newWrapper.maxBodyBytes = r.maxBodyBytes
+	newWrapper.multipartMemory = r.multipartMemory
+	newWrapper.noContent = r.noContent
+	newWrapper.keepAlive = r.keepAlive
+	newWrapper.problemDetails = r.problemDetails
+	newWrapper.hideErrors = r.hideErrors
+	newWrapper.panicHook = r.panicHook
+	newWrapper.bodyCodecs = r.bodyCodecs
 	return newWrapper
 }
 
@@ -192,6 +259,83 @@ func (r *RestfulWrapper) ErrorHandler(errorHandler ErrorHandler) *RestfulWrapper
 	return r
 }
 
+// MaxBodyBytes sets the default maximum size of a request body, in bytes, for routes added with Register and Handle.
+//
+// A request with a larger body will fail with a 413 error.  The "body" tag's "maxbytes" option overrides this
+// for a single route.  If 0, there is no limit.
+func (r *RestfulWrapper) MaxBodyBytes(maxBodyBytes int64) *RestfulWrapper {
+	r.maxBodyBytes = maxBodyBytes
+	return r
+}
+
+// MultipartMaxMemory sets the maximum number of bytes of a multipart form that will be kept in memory, for routes
+// added with Register and Handle; the rest of the form (such as large files) will be stored in temporary files.
+//
+// If 0, the default (10MB) is used.
+func (r *RestfulWrapper) MultipartMaxMemory(maxMemory int64) *RestfulWrapper {
+	r.multipartMemory = maxMemory
+	return r
+}
+
+// NoContent sets whether responses without a value will be "204 No Content" for routes added with Register and Handle.
+//
+// A response has no value when the method has no non-error return value or when it returns a nil pointer.
+// Otherwise, such a response is "200 OK" with an empty (or "null") body.  The "status" tag overrides this for a single route.
+func (r *RestfulWrapper) NoContent(enabled bool) *RestfulWrapper {
+	r.noContent = enabled
+	return r
+}
+
+// ServerSentEventKeepAlive sets how often a keep-alive comment is sent on a Server-Sent Events stream while there
+// are no events, for routes added with Register and Handle.
+//
+// If 0, the default (15 seconds) is used.  If negative, no keep-alive comments are sent.
+func (r *RestfulWrapper) ServerSentEventKeepAlive(interval time.Duration) *RestfulWrapper {
+	r.keepAlive = interval
+	return r
+}
+
+// ProblemDetails sets whether errors will be written as RFC 9457 Problem Details ("application/problem+json").
+//
+// This applies to the built-in errors (such as APIResponseError, APIBodyError, and the parameter errors) and to any
+// error that does not implement ErrorWriter; any other ErrorWriter still writes itself.  See ProblemDetailsOutput.
+func (r *RestfulWrapper) ProblemDetails(enabled bool) *RestfulWrapper {
+	r.problemDetails = enabled
+	return r
+}
+
+// HideInternalErrors sets whether internal errors will be hidden from clients (for production use).
+//
+// An internal error is any error that does not implement ErrorWriter (after the ErrorHandler, if any); normally, its
+// type and message are sent to the client with the 500 response.  When hidden, the client gets an opaque message and a
+// generated error ID instead, both in the body ("errorId") and in the ErrorIDHeader header, and the full error is
+// logged with that ID.
+func (r *RestfulWrapper) HideInternalErrors(enabled bool) *RestfulWrapper {
+	r.hideErrors = enabled
+	return r
+}
+
+// OnPanic sets the hook that will be called whenever a route panics.
+//
+// Panics are always recovered: the stack trace is logged, and the panic is converted into a *PanicError that goes
+// through the ErrorHandler and is written like any other error (a 500, by default).  The hook is called before that,
+// so that the panic can be reported elsewhere.  If the response has already started (such as a stream or a WebSocket),
+// then nothing more is written.
+func (r *RestfulWrapper) OnPanic(panicHook PanicHook) *RestfulWrapper {
+	r.panicHook = panicHook
+	return r
+}
+
+// errorOptions returns the options for writing errors for the routes added to the wrapper.
+func (r *RestfulWrapper) errorOptions() errorOptions {
+	return errorOptions{
+		problemDetails:     r.problemDetails,
+		hideInternalErrors: r.hideErrors,
+		errorHandler:       r.errorHandler,
+		panicHook:          r.panicHook,
+	}
+}
+
 // RestfulRouteWrapper wraps a route and ultimately will result in a `*restful.RouteBuilder` value.
 type RestfulRouteWrapper struct {
 	ws                *RestfulWrapper               // This is the parent wrapper of this route.
@@ -232,7 +376,7 @@ func (r *RestfulRouteWrapper) RouteBuilder() *restful.RouteBuilder {
 	routeBuilder := r.ws.ws.
 		Method(r.method).
 		Path(r.path).
-		To(restfulFunctionWrapper(r.functionWithError)).
+		To(restfulFunctionWrapper(r.functionWithError, r.ws.errorOptions())).
 		Filter(filterSetAttributes(r.ws.attributes)).
 		Do(r.doFunctions...)
 
@@ -256,7 +400,48 @@ func (r *RestfulRouteWrapper) RouteBuilder() *restful.RouteBuilder {
 //
 // The path given will be used as the root for any endpoints.  Note that the RestfulWrapper
 // itself may already have its own path root; this new path will be appended to that.
+//
+// This will panic if any method cannot be registered (including when its route conflicts with another
+// route); see TryRegister for a version that returns an error.
 func (r *RestfulWrapper) Register(ctx context.Context, path string, f any) {
+	err := r.TryRegister(ctx, path, f)
+	if err != nil {
+		slog.ErrorContext(ctx, fmt.Sprintf("Could not register (%T): %v", f, err))
+		panic(err)
+	}
+}
+
+// TryRegister is like Register, but it returns an error instead of panicking.
+//
+// Every method (including those of any API structs embedded with "httppath") is parsed and checked for
+// conflicts with the other routes in the web service before any routes are added; if any of them fail,
+// then no routes are added and a *RegisterError is returned that lists every failure.
+func (r *RestfulWrapper) TryRegister(ctx context.Context, path string, f any) error {
+	var pendingRoutes []pendingRoute
+	var failures []*RegisterFailure
+	r.prepareRegister(ctx, path, f, &pendingRoutes, &failures)
+	failures = append(failures, r.findRouteConflicts(pendingRoutes)...)
+	if len(failures) > 0 {
+		return &RegisterError{
+			Failures: failures,
+		}
+	}
+
+	for _, pendingRoute := range pendingRoutes {
+		r.ws.Route(pendingRoute.routeBuilder)
+	}
+	return nil
+}
+
+// pendingRoute is a route that has been prepared by Register but not yet added to the web service.
+type pendingRoute struct {
+	info         *RestfulFunctionInfo  // This is the parsed information about the method.
+	routeBuilder *restful.RouteBuilder // This is the route builder that will be added.
+}
+
+// prepareRegister parses every method of the given API struct (and of any embedded API structs) and
+// adds the resulting routes and failures to the given lists.
+func (r *RestfulWrapper) prepareRegister(ctx context.Context, path string, f any, pendingRoutes *[]pendingRoute, failures *[]*RegisterFailure) {
 	var fValue = reflect.ValueOf(f)
 
 	slog.DebugContext(ctx, fmt.Sprintf("Registering: %s at %s", fValue.Type().String(), path))
@@ -266,42 +451,29 @@ func (r *RestfulWrapper) Register(ctx context.Context, path string, f any) {
 
 		info, err := ParseRestfulFunction(methodValue.Interface())
 		if err != nil {
-			slog.ErrorContext(ctx, fmt.Sprintf("Could not parse function (%T): %v: %v", f, fValue.Type().Method(i).Name, err))
-			panic(fmt.Errorf("could not parse function (%T): %v: %w", f, fValue.Type().Method(i).Name, err))
-		}
-
-		routePath := "/" + strings.Trim(path, "/")
-		if cleanPath := strings.Trim(info.HTTPPath, "/"); cleanPath != "" {
-			if !strings.HasSuffix(routePath, "/") {
-				routePath += "/"
+			slog.DebugContext(ctx, fmt.Sprintf("Could not parse function (%T): %v: %v", f, fValue.Type().Method(i).Name, err))
+			failure := &RegisterFailure{
+				Type:   fValue.Type(),
+				Method: fValue.Type().Method(i).Name,
+				Path:   path,
+				Err:    err,
 			}
-			routePath += cleanPath
-		}
-		info.HTTPPath = r.path + routePath // Set HTTPPath to the full path within the web service.
-
-		routeWrapper := r.Method(info.HTTPMethod)
-		routeWrapper.Path(routePath)
-		routeWrapper.functionWithError = info.CreateFunctionWithError(r.errorHandler)
-		{
-			fs := []func(*restful.RouteBuilder){
-				func(builder *restful.RouteBuilder) {
-					builder.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
-						ctx := req.Request.Context()
-						ctx = r.applyContextActions(ctx, info)
-						req.Request = req.Request.WithContext(ctx)
-						chain.ProcessFilter(req, resp)
-					})
-				},
+			var fieldError *FieldError
+			if errors.As(err, &fieldError) {
+				failure.Field = fieldError.Field
 			}
-			fs = append(fs, routeWrapper.doFunctions...)
-			routeWrapper.doFunctions = fs
+			*failures = append(*failures, failure)
+			continue
 		}
 
-		routeBuilder := routeWrapper.RouteBuilder()
-		info.UpdateRouteBuilder(routeBuilder)
+		info.ReceiverType = fValue.Type()
+		info.MethodName = fValue.Type().Method(i).Name
 
-		slog.DebugContext(ctx, fmt.Sprintf("Registering function: %s at %s %s", fValue.Type().Method(i).Name, routeWrapper.method, routeWrapper.path))
-		r.ws.Route(routeBuilder)
+		routeBuilder := r.prepareRoute(ctx, path, info, info.CreateFunctionWithError(r.errorHandler))
+		*pendingRoutes = append(*pendingRoutes, pendingRoute{
+			info:         info,
+			routeBuilder: routeBuilder,
+		})
 	}
 
 	for fValue.Kind() == reflect.Pointer {
@@ -333,13 +505,298 @@ func (r *RestfulWrapper) Register(ctx context.Context, path string, f any) {
 						newValue := reflect.New(fieldValue.Type())
 						fieldInterface = newValue.Interface()
 					}
-					r.Register(ctx, strings.TrimRight(path, "/")+"/"+strings.TrimLeft(tagPartValue, "/"), fieldInterface)
+					r.prepareRegister(ctx, strings.TrimRight(path, "/")+"/"+strings.TrimLeft(tagPartValue, "/"), fieldInterface, pendingRoutes, failures)
 				}
 			}
 		}
 	}
 }
 
+// findRouteConflicts returns a failure for every pending route that has the same HTTP method and an
+// indistinguishable path from another route, whether that route is already in the web service or is also pending.
+//
+// Paths are indistinguishable when "restful" cannot tell which one a request is for (for example, "/items/{id}"
+// and "/items/{name}", or "/items/{id}" and "/items/{id:[0-9]+}").  A literal segment is always preferred over a
+// path parameter, so "/items/{id}" and "/items/latest" do not conflict.
+func (r *RestfulWrapper) findRouteConflicts(pendingRoutes []pendingRoute) []*RegisterFailure {
+	type existingRoute struct {
+		method string
+		path   string
+		info   *RestfulFunctionInfo
+	}
+	var existingRoutes []existingRoute
+	for _, route := range r.ws.Routes() {
+		info, _ := route.Metadata[routeMetadataFunctionInfo].(*RestfulFunctionInfo)
+		existingRoutes = append(existingRoutes, existingRoute{
+			method: route.Method,
+			path:   route.Path,
+			info:   info,
+		})
+	}
+
+	var failures []*RegisterFailure
+	for _, pendingRoute := range pendingRoutes {
+		info := pendingRoute.info
+		for _, existing := range existingRoutes {
+			if existing.method != info.HTTPMethod || !routePathsOverlap(existing.path, info.HTTPPath) {
+				continue
+			}
+
+			conflictError := &RouteConflictError{
+				HTTPMethod:    existing.method,
+				HTTPPath:      existing.path,
+				OtherHTTPPath: info.HTTPPath,
+			}
+			if existing.info != nil {
+				conflictError.Type = existing.info.ReceiverType
+				conflictError.Method = existing.info.MethodName
+			}
+			failures = append(failures, &RegisterFailure{
+				Type:   info.ReceiverType,
+				Method: info.MethodName,
+				Path:   info.HTTPPath,
+				Err:    conflictError,
+			})
+			break
+		}
+		existingRoutes = append(existingRoutes, existingRoute{
+			method: info.HTTPMethod,
+			path:   info.HTTPPath,
+			info:   info,
+		})
+	}
+	return failures
+}
+
+// routePathsOverlap returns true if "restful" cannot tell the paths apart, so that one of them shadows the other.
+//
+// The paths are compared segment by segment:
+//   - Two literal segments overlap if they are the same.
+//   - A literal segment never overlaps with a path parameter, since "restful" prefers the literal.
+//   - Two path parameters overlap if either has no regular expression or if they have the same one.  (Two
+//     different regular expressions are assumed not to overlap.)
+//   - A wildcard path parameter (such as "{rest:*}") only overlaps with another wildcard, which covers the
+//     remainder of both paths.
+func routePathsOverlap(path string, otherPath string) bool {
+	segments := routePathSegments(path)
+	otherSegments := routePathSegments(otherPath)
+	for i := 0; ; i++ {
+		if i >= len(segments) || i >= len(otherSegments) {
+			return len(segments) == len(otherSegments)
+		}
+		segment := segments[i]
+		otherSegment := otherSegments[i]
+		if segment.expression == "*" && otherSegment.expression == "*" {
+			return true
+		}
+		if !routePathSegmentsOverlap(segment, otherSegment) {
+			return false
+		}
+	}
+}
+
+// routePathSegment is a single segment of a route's path.
+type routePathSegment struct {
+	literal    string // This is the literal value, if this is not a path parameter.
+	parameter  bool   // If true, then this is a path parameter.
+	expression string // This is the regular expression of the path parameter, if any.
+}
+
+// routePathSegments splits the path into its segments, ignoring any leading, trailing, and duplicate slashes.
+func routePathSegments(path string) []routePathSegment {
+	var segments []routePathSegment
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			segment := routePathSegment{parameter: true}
+			if colon := strings.Index(part, ":"); colon >= 0 {
+				segment.expression = part[colon+1 : len(part)-1]
+			}
+			segments = append(segments, segment)
+			continue
+		}
+		segments = append(segments, routePathSegment{literal: part})
+	}
+	return segments
+}
+
+// routePathSegmentsOverlap returns true if "restful" cannot tell the segments apart.
+func routePathSegmentsOverlap(segment routePathSegment, otherSegment routePathSegment) bool {
+	switch {
+	case !segment.parameter && !otherSegment.parameter:
+		return segment.literal == otherSegment.literal
+	case !segment.parameter || !otherSegment.parameter:
+		return false
+	case segment.expression == "*" || otherSegment.expression == "*":
+		return false
+	case segment.expression == "" || otherSegment.expression == "":
+		return true
+	default:
+		return segment.expression == otherSegment.expression
+	}
+}
+
+// RouteConflictError is used when a route has the same HTTP method as another route and a path that overlaps with it.
+type RouteConflictError struct {
+	HTTPMethod    string       // This is the HTTP method of the other route.
+	HTTPPath      string       // This is the path of the other route.
+	OtherHTTPPath string       // This is the path of the route that conflicts with it.
+	Type          reflect.Type // This is the type of the API struct of the other route, if it was added with Register.
+	Method        string       // This is the name of the method (or function) of the other route, if it was added with Register or Handle.
+}
+
+var _ error = (*RouteConflictError)(nil)
+
+func (e *RouteConflictError) Error() string {
+	if e.Type == nil && e.Method != "" {
+		return fmt.Sprintf("route %s %s conflicts with route %s %s: %s", e.HTTPMethod, e.OtherHTTPPath, e.HTTPMethod, e.HTTPPath, e.Method)
+	}
+	if e.Type == nil {
+		return fmt.Sprintf("route %s %s conflicts with existing route %s %s", e.HTTPMethod, e.OtherHTTPPath, e.HTTPMethod, e.HTTPPath)
+	}
+	return fmt.Sprintf("route %s %s conflicts with route %s %s (%s): %s", e.HTTPMethod, e.OtherHTTPPath, e.HTTPMethod, e.HTTPPath, e.Type.String(), e.Method)
+}
+
+// prepareRoute returns the route builder for the given function, whose path will be rooted at the given path.
+//
+// This also updates the info's HTTPPath to be the full path within the web service.
+func (r *RestfulWrapper) prepareRoute(ctx context.Context, path string, info *RestfulFunctionInfo, functionWithError RestfulFunctionWithError) *restful.RouteBuilder {
+	routePath := "/" + strings.Trim(path, "/")
+	if cleanPath := strings.Trim(info.HTTPPath, "/"); cleanPath != "" {
+		if !strings.HasSuffix(routePath, "/") {
+			routePath += "/"
+		}
+		routePath += cleanPath
+	}
+	info.HTTPPath = r.path + routePath // Set HTTPPath to the full path within the web service.
+	if info.MaxBodyBytes == 0 {
+		info.MaxBodyBytes = r.maxBodyBytes
+	}
+	info.MultipartMaxMemory = r.multipartMemory
+	info.NoContent = r.noContent
+	info.ServerSentEventKeepAlive = r.keepAlive
+	info.ProblemDetails = r.problemDetails
+	if len(info.Produces) == 0 {
+		// The response content type is negotiated against this, so keep a copy of the wrapper's.
+		info.Produces = slices.Clone(r.produces)
+	}
+	info.bodyCodecs = r.bodyCodecs
+	if info.bodyUsesCodecs {
+		info.BodyCodecContentTypes = bodyCodecContentTypes(r.bodyCodecs)
+	}
+	if len(info.Consumes) == 0 && len(info.BodyCodecContentTypes) > 0 {
+		// The body can be decoded with whatever the wrapper consumes (JSON, by default) as well as with the body codecs.
+		consumes := slices.Clone(r.consumes)
+		if len(consumes) == 0 {
+			consumes = append(consumes, restful.MIME_JSON)
+		}
+		for _, contentType := range info.BodyCodecContentTypes {
+			if !slices.Contains(consumes, contentType) {
+				consumes = append(consumes, contentType)
+			}
+		}
+		info.Consumes = consumes
+	}
+
+	routeWrapper := r.Method(info.HTTPMethod)
+	routeWrapper.Path(routePath)
+	routeWrapper.functionWithError = functionWithError
+	{
+		fs := []func(*restful.RouteBuilder){
+			func(builder *restful.RouteBuilder) {
+				builder.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+					ctx := req.Request.Context()
+					ctx = r.applyContextActions(ctx, info)
+					req.Request = req.Request.WithContext(ctx)
+					chain.ProcessFilter(req, resp)
+				})
+			},
+		}
+		fs = append(fs, routeWrapper.doFunctions...)
+		routeWrapper.doFunctions = fs
+	}
+
+	routeBuilder := routeWrapper.RouteBuilder()
+	info.UpdateRouteBuilder(routeBuilder)
+	routeBuilder.Metadata(routeMetadataFunctionInfo, info)
+
+	slog.DebugContext(ctx, fmt.Sprintf("Registering function: %s at %s %s", info.MethodName, routeWrapper.method, routeWrapper.path))
+	return routeBuilder
+}
+
+// RegisterError is returned by TryRegister when one or more methods could not be registered.
+type RegisterError struct {
+	Failures []*RegisterFailure // This is the list of methods that could not be registered.
+}
+
+var _ error = (*RegisterError)(nil)
+
+func (e *RegisterError) Error() string {
+	if len(e.Failures) == 1 {
+		return e.Failures[0].Error()
+	}
+
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, failure.Error())
+	}
+	return fmt.Sprintf("could not register %d functions: %s", len(e.Failures), strings.Join(messages, "; "))
+}
+
+func (e *RegisterError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure)
+	}
+	return errs
+}
+
+// RegisterFailure describes a single method that could not be registered.
+type RegisterFailure struct {
+	Type   reflect.Type // This is the type of the API struct; this is nil for functions registered with Handle.
+	Method string       // This is the name of the method (or the full name of the function for Handle).
+	Field  string       // This is the name of the metadata field that could not be parsed, if any.
+	Path   string       // This is the path that the API struct was being registered at.
+	Err    error        // This is the underlying error.
+}
+
+var _ error = (*RegisterFailure)(nil)
+
+func (e *RegisterFailure) Error() string {
+	action := "could not parse function"
+	var conflictError *RouteConflictError
+	if errors.As(e.Err, &conflictError) {
+		// The function itself is fine; its route just cannot be added.
+		action = "could not add route"
+	}
+	if e.Type == nil {
+		return fmt.Sprintf("%s: %s: %v", action, e.Method, e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %s: %v", action, e.Type.String(), e.Method, e.Err)
+}
+
+func (e *RegisterFailure) Unwrap() error {
+	return e.Err
+}
+
+// Routes returns the parsed information for every route that was added with Register, in the order
+// that they were added.
+//
+// This includes the routes from every session of the wrapper, since they all share the same web service.
+func (r *RestfulWrapper) Routes() []*RestfulFunctionInfo {
+	var infos []*RestfulFunctionInfo
+	for _, route := range r.ws.Routes() {
+		info, ok := route.Metadata[routeMetadataFunctionInfo].(*RestfulFunctionInfo)
+		if !ok {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
 func (w *RestfulWrapper) ContextAction(f ...ContextAction) *RestfulWrapper {
 	w.contextActions = append(w.contextActions, f...)
 	return w
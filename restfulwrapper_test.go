@@ -1,17 +1,25 @@
 package restfulwrapper_test
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/stretchr/testify/assert"
@@ -129,6 +137,154 @@ func (a *SubAPI) GetEndpoint6(ctx context.Context, meta GetEndpoint6Metadata) (s
 	return "", fmt.Errorf("wrap: %w", ErrCustomNotFound2)
 }
 
+type GetEndpoint7Metadata struct {
+	restfulwrapper.HTTPMethodGET
+	_      string `api:"httppath:/endpoint7"`
+	_      string `api:"doc" description:"Endpoint 7 doc."`
+	_      string `api:"notes" description:"Endpoint 7 notes"`
+	Limit  int    `api:"query:limit;required" description:"Limit parameter."`
+	Tenant string `api:"header:X-Tenant;required" description:"Tenant header."`
+}
+
+func (a *SubAPI) GetEndpoint7(ctx context.Context, meta GetEndpoint7Metadata) (string, error) {
+	return fmt.Sprintf("endpoint7:%s:%d", meta.Tenant, meta.Limit), nil
+}
+
+type GetEndpoint8Metadata struct {
+	restfulwrapper.HTTPMethodGET
+	_       string `api:"httppath:/endpoint8"`
+	_       string `api:"doc" description:"Endpoint 8 doc."`
+	_       string `api:"notes" description:"Endpoint 8 notes"`
+	Session string `api:"cookie:session;required" description:"Session cookie."`
+	Page    int    `api:"cookie:page" default:"1" description:"Page cookie."`
+}
+
+func (a *SubAPI) GetEndpoint8(ctx context.Context, meta GetEndpoint8Metadata) (string, error) {
+	return fmt.Sprintf("endpoint8:%s:%d", meta.Session, meta.Page), nil
+}
+
+type PostEndpoint9Metadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_     string   `api:"httppath:/endpoint9"`
+	_     string   `api:"doc" description:"Endpoint 9 doc."`
+	_     string   `api:"notes" description:"Endpoint 9 notes"`
+	Name  string   `api:"form:name;required" description:"Name field."`
+	Count int      `api:"form:count" default:"1" description:"Count field." validate:"min:1"`
+	Tags  []string `api:"form:tag" description:"Tag fields."`
+}
+
+func (a *SubAPI) PostEndpoint9(ctx context.Context, meta PostEndpoint9Metadata) (string, error) {
+	return fmt.Sprintf("endpoint9:%s:%d:%s", meta.Name, meta.Count, strings.Join(meta.Tags, ",")), nil
+}
+
+type PostEndpoint10Metadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_         string                  `api:"httppath:/endpoint10"`
+	_         string                  `api:"doc" description:"Endpoint 10 doc."`
+	_         string                  `api:"notes" description:"Endpoint 10 notes"`
+	Name      string                  `api:"form:name"`
+	Document  *multipart.FileHeader   `api:"file:document;required;maxsize:16;types:text/*"`
+	Reader    io.ReadCloser           `api:"file:raw"`
+	Documents []*multipart.FileHeader `api:"file:extra"`
+}
+
+func (a *SubAPI) PostEndpoint10(ctx context.Context, meta PostEndpoint10Metadata) (string, error) {
+	var raw []byte
+	if meta.Reader != nil {
+		defer meta.Reader.Close()
+
+		var err error
+		raw, err = io.ReadAll(meta.Reader)
+		if err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("endpoint10:%s:%s:%d:%s:%d", meta.Name, meta.Document.Filename, meta.Document.Size, raw, len(meta.Documents)), nil
+}
+
+type PostEndpoint11Metadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_     string                            `api:"httppath:/endpoint11"`
+	_     string                            `api:"doc" description:"Endpoint 11 doc."`
+	_     string                            `api:"notes" description:"Endpoint 11 notes"`
+	Parts iter.Seq2[*multipart.Part, error] `api:"body"`
+}
+
+func (a *SubAPI) PostEndpoint11(ctx context.Context, meta PostEndpoint11Metadata) (string, error) {
+	var names []string
+	var size int64
+	for part, err := range meta.Parts {
+		if err != nil {
+			return "", err
+		}
+		n, err := io.Copy(io.Discard, part)
+		if err != nil {
+			return "", err
+		}
+		names = append(names, part.FormName())
+		size += n
+	}
+	return fmt.Sprintf("endpoint11:%s:%d", strings.Join(names, ","), size), nil
+}
+
+type PostEndpoint12Metadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_      string            `api:"httppath:/endpoint12"`
+	_      string            `api:"doc" description:"Endpoint 12 doc."`
+	_      string            `api:"notes" description:"Endpoint 12 notes"`
+	Reader *multipart.Reader `api:"body"`
+}
+
+func (a *SubAPI) PostEndpoint12(ctx context.Context, meta PostEndpoint12Metadata) (string, error) {
+	part, err := meta.Reader.NextPart()
+	if err != nil {
+		return "", err
+	}
+	contents, err := io.ReadAll(part)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("endpoint12:%s:%s", part.FormName(), contents), nil
+}
+
+type PostEndpoint13Body struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+type PostEndpoint13Metadata struct {
+	restfulwrapper.HTTPMethodPOST
+	_    string             `api:"httppath:/endpoint13"`
+	_    string             `api:"doc" description:"Endpoint 13 doc."`
+	_    string             `api:"notes" description:"Endpoint 13 notes"`
+	Body PostEndpoint13Body `api:"body:consumes:application/json,application/x-www-form-urlencoded,multipart/form-data"`
+}
+
+func (a *SubAPI) PostEndpoint13(ctx context.Context, meta PostEndpoint13Metadata) (string, error) {
+	return fmt.Sprintf("endpoint13:%s:%d:%s", meta.Body.Name, meta.Body.Count, strings.Join(meta.Body.Tags, ",")), nil
+}
+
+type GetEndpoint14Metadata struct {
+	restfulwrapper.HTTPMethodGET
+	_     string `api:"httppath:/endpoint14"`
+	_     string `api:"doc" description:"Endpoint 14 doc."`
+	_     string `api:"notes" description:"Endpoint 14 notes"`
+	Limit int    `api:"query:limit" description:"Limit parameter." validate:"min:1;max:100"`
+}
+
+func (a *SubAPI) GetEndpoint14(ctx context.Context, meta GetEndpoint14Metadata) (string, error) {
+	return fmt.Sprintf("endpoint14:%d", meta.Limit), nil
+}
+
+// multipartTestFile is a file to upload as part of a multipart form.
+type multipartTestFile struct {
+	Name        string
+	Filename    string
+	ContentType string
+	Contents    string
+}
+
 func TestRestfulWrapper(t *testing.T) {
 	if value := os.Getenv("DEBUG"); value == "1" || value == "true" {
 		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
@@ -368,5 +524,1724 @@ func TestRestfulWrapper(t *testing.T) {
 		assert.Equal(t, `Not Found`, output["message"])
 		assert.NotContains(t, output, "parameter")
 	})
+	t.Run("GET /api/v1/subapi/endpoint7 without required query", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint7", nil)
+		require.Nil(t, err)
+
+		req.Header.Set("X-Tenant", "tenant1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIQueryParameterError`, output["type"])
+		assert.Equal(t, `missing required query parameter`, output["message"])
+		assert.Equal(t, `limit`, output["parameter"])
+	})
+	t.Run("GET /api/v1/subapi/endpoint7 without required header", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint7?limit=5", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIHeaderParameterError`, output["type"])
+		assert.Equal(t, `missing required header parameter`, output["message"])
+		assert.Equal(t, `X-Tenant`, output["parameter"])
+	})
+	t.Run("GET /api/v1/subapi/endpoint7", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint7?limit=5", nil)
+		require.Nil(t, err)
+
+		req.Header.Set("X-Tenant", "tenant1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint7:tenant1:5"`, string(bodyBytes))
+	})
+	t.Run("GET /api/v1/subapi/endpoint8 (missing cookie)", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint8", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APICookieParameterError`, output["type"])
+		assert.Equal(t, `session`, output["parameter"])
+	})
+	t.Run("GET /api/v1/subapi/endpoint8 (bad cookie)", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint8", nil)
+		require.Nil(t, err)
+
+		req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+		req.AddCookie(&http.Cookie{Name: "page", Value: "bogus"})
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APICookieParameterError`, output["type"])
+		assert.Equal(t, `page`, output["parameter"])
+	})
+	t.Run("GET /api/v1/subapi/endpoint8", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint8", nil)
+		require.Nil(t, err)
+
+		req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint8:abc:1"`, string(bodyBytes))
+	})
+	newMultipartRequest := func(t *testing.T, url string, fields map[string]string, files []multipartTestFile) *http.Request {
+		var body bytes.Buffer
+		multipartWriter := multipart.NewWriter(&body)
+		for name, value := range fields {
+			require.Nil(t, multipartWriter.WriteField(name, value))
+		}
+		for _, file := range files {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, file.Name, file.Filename))
+			header.Set("Content-Type", file.ContentType)
+			part, err := multipartWriter.CreatePart(header)
+			require.Nil(t, err)
+			_, err = part.Write([]byte(file.Contents))
+			require.Nil(t, err)
+		}
+		require.Nil(t, multipartWriter.Close())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+		return req
+	}
+	t.Run("POST /api/v1/subapi/endpoint10", func(t *testing.T) {
+		req := newMultipartRequest(t, server.URL+"/api/v1/subapi/endpoint10", map[string]string{"name": "upload"}, []multipartTestFile{
+			{Name: "document", Filename: "a.txt", ContentType: "text/plain", Contents: "hello"},
+			{Name: "raw", Filename: "b.bin", ContentType: "application/octet-stream", Contents: "raw-data"},
+			{Name: "extra", Filename: "c.txt", ContentType: "text/plain", Contents: "c"},
+			{Name: "extra", Filename: "d.txt", ContentType: "text/plain", Contents: "d"},
+		})
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint10:upload:a.txt:5:raw-data:2"`, string(bodyBytes))
+	})
+	t.Run("POST /api/v1/subapi/endpoint10 (errors)", func(t *testing.T) {
+		rows := []struct {
+			Description string
+			Files       []multipartTestFile
+			Message     string
+		}{
+			{
+				Description: "missing file",
+				Files:       nil,
+				Message:     "document: missing required file",
+			},
+			{
+				Description: "file too large",
+				Files:       []multipartTestFile{{Name: "document", Filename: "a.txt", ContentType: "text/plain", Contents: "this is more than sixteen bytes"}},
+				Message:     `document: file "a.txt" is too large (maximum 16 bytes)`,
+			},
+			{
+				Description: "wrong content type",
+				Files:       []multipartTestFile{{Name: "document", Filename: "a.png", ContentType: "image/png", Contents: "png"}},
+				Message:     `document: file "a.png" has an unsupported content type: "image/png"`,
+			},
+		}
+		for rowIndex, row := range rows {
+			t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+				req := newMultipartRequest(t, server.URL+"/api/v1/subapi/endpoint10", nil, row.Files)
+
+				resp, err := http.DefaultClient.Do(req)
+				require.Nil(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+				bodyBytes, err := io.ReadAll(resp.Body)
+				require.Nil(t, err)
+
+				var output map[string]string
+				err = json.Unmarshal(bodyBytes, &output)
+				require.Nil(t, err)
+				assert.Equal(t, `*restfulwrapper.APIBodyError`, output["type"])
+				assert.Equal(t, row.Message, output["message"])
+			})
+		}
+	})
+	t.Run("POST /api/v1/subapi/endpoint11", func(t *testing.T) {
+		req := newMultipartRequest(t, server.URL+"/api/v1/subapi/endpoint11", nil, []multipartTestFile{
+			{Name: "first", Filename: "a.bin", ContentType: "application/octet-stream", Contents: strings.Repeat("a", 100000)},
+			{Name: "second", Filename: "b.bin", ContentType: "application/octet-stream", Contents: "b"},
+		})
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint11:first,second:100001"`, string(bodyBytes))
+	})
+	t.Run("POST /api/v1/subapi/endpoint11 (wrong content type)", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint11", strings.NewReader(`{}`))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+	t.Run("POST /api/v1/subapi/endpoint12", func(t *testing.T) {
+		req := newMultipartRequest(t, server.URL+"/api/v1/subapi/endpoint12", map[string]string{"field": "value"}, nil)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint12:field:value"`, string(bodyBytes))
+	})
+	t.Run("POST /api/v1/subapi/endpoint13", func(t *testing.T) {
+		rows := []struct {
+			ContentType string
+			Body        string
+			Code        int
+			Output      string
+		}{
+			{ContentType: "application/x-www-form-urlencoded", Body: "name=a&count=2&tags=x&tags=y", Code: http.StatusOK, Output: `"endpoint13:a:2:x,y"`},
+			{ContentType: "application/json", Body: `{"name":"a","count":2,"tags":["x","y"]}`, Code: http.StatusOK, Output: `"endpoint13:a:2:x,y"`},
+			{ContentType: "multipart/form-data; boundary=b", Body: "--b\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\na\r\n--b\r\nContent-Disposition: form-data; name=\"count\"\r\n\r\n2\r\n--b--\r\n", Code: http.StatusOK, Output: `"endpoint13:a:2:"`},
+			{ContentType: "application/x-www-form-urlencoded", Body: "name=a&count=bogus", Code: http.StatusBadRequest},
+			{ContentType: "text/plain", Body: "name=a", Code: http.StatusUnsupportedMediaType},
+		}
+		for rowIndex, row := range rows {
+			t.Run(fmt.Sprintf("%d/%s", rowIndex, row.ContentType), func(t *testing.T) {
+				req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint13", strings.NewReader(row.Body))
+				require.Nil(t, err)
+
+				req.Header.Set("Content-Type", row.ContentType)
+
+				resp, err := http.DefaultClient.Do(req)
+				require.Nil(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, row.Code, resp.StatusCode)
+				if row.Code == http.StatusOK {
+					bodyBytes, err := io.ReadAll(resp.Body)
+					require.Nil(t, err)
+					require.Equal(t, row.Output, string(bodyBytes))
+				}
+			})
+		}
+	})
+	t.Run("GET /api/v1/subapi/endpoint14", func(t *testing.T) {
+		rows := []struct {
+			Query   string
+			Code    int
+			Output  string
+			Message string
+		}{
+			{Query: "", Code: http.StatusOK, Output: `"endpoint14:0"`},
+			{Query: "?limit=5", Code: http.StatusOK, Output: `"endpoint14:5"`},
+			{Query: "?limit=500", Code: http.StatusBadRequest, Message: `must be at most 100`},
+			{Query: "?limit=0", Code: http.StatusBadRequest, Message: `must be at least 1`},
+		}
+		for rowIndex, row := range rows {
+			t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Query), func(t *testing.T) {
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/subapi/endpoint14"+row.Query, nil)
+				require.Nil(t, err)
+
+				resp, err := http.DefaultClient.Do(req)
+				require.Nil(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, row.Code, resp.StatusCode)
+
+				bodyBytes, err := io.ReadAll(resp.Body)
+				require.Nil(t, err)
+				if row.Code == http.StatusOK {
+					require.Equal(t, row.Output, string(bodyBytes))
+				} else {
+					var output map[string]string
+					err = json.Unmarshal(bodyBytes, &output)
+					require.Nil(t, err)
+					assert.Equal(t, `*restfulwrapper.APIQueryParameterError`, output["type"])
+					assert.Equal(t, row.Message, output["message"])
+					assert.Equal(t, `limit`, output["parameter"])
+				}
+			})
+		}
+	})
+	t.Run("POST /api/v1/subapi/endpoint9 (urlencoded)", func(t *testing.T) {
+		form := url.Values{}
+		form.Set("name", "widget")
+		form.Add("tag", "a")
+		form.Add("tag", "b")
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint9", strings.NewReader(form.Encode()))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint9:widget:1:a,b"`, string(bodyBytes))
+	})
+	t.Run("POST /api/v1/subapi/endpoint9 (multipart)", func(t *testing.T) {
+		var body bytes.Buffer
+		multipartWriter := multipart.NewWriter(&body)
+		require.Nil(t, multipartWriter.WriteField("name", "widget"))
+		require.Nil(t, multipartWriter.WriteField("count", "3"))
+		require.Nil(t, multipartWriter.Close())
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint9", &body)
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+		require.Equal(t, `"endpoint9:widget:3:"`, string(bodyBytes))
+	})
+	t.Run("POST /api/v1/subapi/endpoint9 (bad count)", func(t *testing.T) {
+		form := url.Values{}
+		form.Set("name", "widget")
+		form.Set("count", "0")
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint9", strings.NewReader(form.Encode()))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIFormParameterError`, output["type"])
+		assert.Equal(t, `must be at least 1`, output["message"])
+		assert.Equal(t, `count`, output["parameter"])
+	})
+	t.Run("POST /api/v1/subapi/endpoint9 (missing name)", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v1/subapi/endpoint9", strings.NewReader(""))
+		require.Nil(t, err)
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		require.Nil(t, err)
+
+		var output map[string]string
+		err = json.Unmarshal(bodyBytes, &output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIFormParameterError`, output["type"])
+		assert.Equal(t, `name`, output["parameter"])
+	})
+
+}
+
+func TestRestfulWrapperRoutes(t *testing.T) {
+	ctx := t.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().Register(ctx, "/v1", &API{})
+	webService.Route(webService.GET("/not-registered").RouteBuilder())
+
+	routes := webService.Routes()
+
+	type routeSummary struct {
+		Method       string
+		Path         string
+		ReceiverType string
+		MethodName   string
+	}
+	var summaries []routeSummary
+	for _, route := range routes {
+		summaries = append(summaries, routeSummary{
+			Method:       route.HTTPMethod,
+			Path:         route.HTTPPath,
+			ReceiverType: route.ReceiverType.String(),
+			MethodName:   route.MethodName,
+		})
+	}
+	assert.Equal(t, []routeSummary{
+		{Method: http.MethodDelete, Path: "/api/v1/endpoint1", ReceiverType: "*restfulwrapper_test.API", MethodName: "DeleteEndpoint1"},
+		{Method: http.MethodGet, Path: "/api/v1/endpoint1", ReceiverType: "*restfulwrapper_test.API", MethodName: "GetEndpoint1"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint14", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint14"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint3", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint3"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint4", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint4"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint5", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint5"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint6", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint6"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint7", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint7"},
+		{Method: http.MethodGet, Path: "/api/v1/subapi/endpoint8", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "GetEndpoint8"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint10", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint10"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint11", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint11"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint12", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint12"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint13", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint13"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint2/{id}", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint2"},
+		{Method: http.MethodPost, Path: "/api/v1/subapi/endpoint9", ReceiverType: "*restfulwrapper_test.SubAPI", MethodName: "PostEndpoint9"},
+	}, summaries)
+
+	for _, route := range routes {
+		if route.MethodName == "PostEndpoint2" {
+			if assert.Equal(t, 1, len(route.PathParameters)) {
+				assert.Equal(t, "id", route.PathParameters[0].Name)
+			}
+		}
+	}
+}
+
+type BadAPI struct {
+	_ BadSubAPI `api:"httppath:/sub"`
+}
 
+type BadMetadata1 struct {
+	restfulwrapper.HTTPMethodGET
+	_     string `api:"httppath:/bad1"`
+	Value string `api:"bogus"`
+}
+
+func (a *BadAPI) GetBad1(ctx context.Context, meta BadMetadata1) error {
+	return nil
+}
+
+type GoodMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_ string `api:"httppath:/good"`
+}
+
+func (a *BadAPI) GetGood(ctx context.Context, meta GoodMetadata) error {
+	return nil
+}
+
+type BadSubAPI struct{}
+
+func (a *BadSubAPI) GetBad2(ctx context.Context, meta struct{}, extra struct{}) error {
+	return nil
+}
+
+func TestRestfulWrapperTryRegister(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("Good", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		err := webService.TryRegister(ctx, "/v1", &API{})
+		require.Nil(t, err)
+		assert.Equal(t, 15, len(webService.Routes()))
+	})
+	t.Run("Bad", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		err := webService.TryRegister(ctx, "/v1", &BadAPI{})
+		require.NotNil(t, err)
+
+		var registerError *restfulwrapper.RegisterError
+		require.ErrorAs(t, err, &registerError)
+		if assert.Equal(t, 2, len(registerError.Failures)) {
+			assert.Equal(t, "*restfulwrapper_test.BadAPI", registerError.Failures[0].Type.String())
+			assert.Equal(t, "GetBad1", registerError.Failures[0].Method)
+			assert.Equal(t, "Value", registerError.Failures[0].Field)
+			assert.Equal(t, "/v1", registerError.Failures[0].Path)
+
+			assert.Equal(t, "*restfulwrapper_test.BadSubAPI", registerError.Failures[1].Type.String())
+			assert.Equal(t, "GetBad2", registerError.Failures[1].Method)
+			assert.Equal(t, "", registerError.Failures[1].Field)
+			assert.Equal(t, "/v1/sub", registerError.Failures[1].Path)
+		}
+		assert.Contains(t, err.Error(), "could not register 2 functions")
+
+		var fieldError *restfulwrapper.FieldError
+		assert.ErrorAs(t, err, &fieldError)
+
+		// Nothing should have been registered.
+		assert.Equal(t, 0, len(webService.Routes()))
+		assert.Equal(t, 0, len(webService.WebService().Routes()))
+	})
+	t.Run("Panic", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		assert.Panics(t, func() {
+			webService.Register(ctx, "/v1", &BadAPI{})
+		})
+	})
+}
+
+type ConflictAPI struct {
+	_ ConflictSubAPI `api:"httppath:/"`
+}
+
+type ConflictMetadata1 struct {
+	restfulwrapper.HTTPMethodGET
+	_  string `api:"httppath:/items/{id}"`
+	ID string `api:"path:id"`
+}
+
+func (a *ConflictAPI) GetItem(ctx context.Context, meta ConflictMetadata1) error {
+	return nil
+}
+
+type ConflictSubAPI struct{}
+
+type ConflictCodeMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_    string `api:"httppath:/codes/{code:[0-9]+}"`
+	Code int    `api:"path:code"`
+}
+
+type ConflictMetadata2 struct {
+	restfulwrapper.HTTPMethodGET
+	_    string `api:"httppath:/items/{name}/"`
+	Name string `api:"path:name"`
+}
+
+func (a *ConflictSubAPI) GetItemByName(ctx context.Context, meta ConflictMetadata2) error {
+	return nil
+}
+
+type ConflictMetadata3 struct {
+	restfulwrapper.HTTPMethodGET
+	_ string `api:"httppath:/items/new"`
+}
+
+func (a *ConflictSubAPI) GetNewItem(ctx context.Context, meta ConflictMetadata3) error {
+	return nil
+}
+
+func TestRestfulWrapperRouteConflicts(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("Within one registration", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		err := webService.TryRegister(ctx, "/v1", &ConflictAPI{})
+		require.NotNil(t, err)
+
+		var registerError *restfulwrapper.RegisterError
+		require.ErrorAs(t, err, &registerError)
+		if assert.Equal(t, 1, len(registerError.Failures)) {
+			assert.Equal(t, "*restfulwrapper_test.ConflictSubAPI", registerError.Failures[0].Type.String())
+			assert.Equal(t, "GetItemByName", registerError.Failures[0].Method)
+
+			var conflictError *restfulwrapper.RouteConflictError
+			if assert.ErrorAs(t, registerError.Failures[0], &conflictError) {
+				assert.Equal(t, http.MethodGet, conflictError.HTTPMethod)
+				assert.Equal(t, "/api/v1/items/{id}", conflictError.HTTPPath)
+				assert.Equal(t, "/api/v1/items/{name}", conflictError.OtherHTTPPath)
+				assert.Equal(t, "*restfulwrapper_test.ConflictAPI", conflictError.Type.String())
+				assert.Equal(t, "GetItem", conflictError.Method)
+			}
+		}
+		assert.Equal(t, 0, len(webService.Routes()))
+	})
+	t.Run("Across registrations", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		err := webService.Session().TryRegister(ctx, "/v1", &ConflictSubAPI{})
+		require.Nil(t, err)
+
+		err = webService.Session().TryRegister(ctx, "/v1/", &ConflictSubAPI{})
+		require.NotNil(t, err)
+
+		var registerError *restfulwrapper.RegisterError
+		require.ErrorAs(t, err, &registerError)
+		assert.Equal(t, 2, len(registerError.Failures))
+		assert.Equal(t, 2, len(webService.Routes()))
+	})
+	t.Run("With a manual route", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		webService.Route(webService.GET("/v1/items/{key}").RouteBuilder())
+
+		err := webService.TryRegister(ctx, "/v1", &ConflictSubAPI{})
+		require.NotNil(t, err)
+
+		var conflictError *restfulwrapper.RouteConflictError
+		if assert.ErrorAs(t, err, &conflictError) {
+			assert.Equal(t, "/api/v1/items/{key}", conflictError.HTTPPath)
+			assert.Nil(t, conflictError.Type)
+		}
+		assert.Contains(t, err.Error(), "could not add route (*restfulwrapper_test.ConflictSubAPI): GetItemByName: route GET /api/v1/items/{name} conflicts with existing route GET /api/v1/items/{key}")
+	})
+	t.Run("Wildcards", func(t *testing.T) {
+		webService := restfulwrapper.WebService("/api")
+		webService.Route(webService.GET("/v1/files/{path:*}").RouteBuilder())
+
+		err := restfulwrapper.TryHandle(ctx, webService, "/v1", func(ctx context.Context, meta struct {
+			restfulwrapper.HTTPMethodGET
+			_    string `api:"httppath:/files/{rest:*}"`
+			Rest string `api:"path:rest"`
+		}) (any, error) {
+			return nil, nil
+		})
+		var conflictError *restfulwrapper.RouteConflictError
+		assert.ErrorAs(t, err, &conflictError)
+	})
+	t.Run("Shadowing templates", func(t *testing.T) {
+		rows := []struct {
+			Path     string
+			Conflict bool
+		}{
+			{Path: "/v1/items/{id}", Conflict: true},
+			{Path: "/v1/items/{id:[0-9]+}", Conflict: true},
+			{Path: "/v1/items/new", Conflict: true},
+			{Path: "/v1/items/latest", Conflict: false},
+			{Path: "/v1/items/{id}/details", Conflict: false},
+			{Path: "/v1/items/{rest:*}", Conflict: false},
+			{Path: "/v1/items/latest/history", Conflict: false},
+			{Path: "/v1/widgets/{id}", Conflict: false},
+			{Path: "/v1/codes/{id}", Conflict: true},
+			{Path: "/v1/codes/123", Conflict: false},
+			{Path: "/v1/codes/latest", Conflict: false},
+			{Path: "/v1/codes/{id:[0-9]+}", Conflict: true},
+			{Path: "/v1/codes/{id:[a-z]+}", Conflict: false},
+		}
+		for _, row := range rows {
+			t.Run(row.Path, func(t *testing.T) {
+				webService := restfulwrapper.WebService("/api")
+				webService.Route(webService.GET(row.Path).RouteBuilder())
+
+				err := webService.TryRegister(ctx, "/v1", &ConflictSubAPI{})
+				if err == nil {
+					err = restfulwrapper.TryHandle(ctx, webService, "/v1", func(ctx context.Context, meta ConflictCodeMetadata) (any, error) {
+						return nil, nil
+					})
+				}
+				if row.Conflict {
+					var conflictError *restfulwrapper.RouteConflictError
+					if assert.ErrorAs(t, err, &conflictError) {
+						assert.Equal(t, "/api"+row.Path, conflictError.HTTPPath)
+					}
+				} else {
+					assert.Nil(t, err)
+				}
+			})
+		}
+	})
+}
+
+type BenchmarkAPI struct{}
+
+type BenchmarkMetadata struct {
+	restfulwrapper.HTTPMethodGET
+	_      string   `api:"httppath:/items/{id}"`
+	_      string   `api:"doc" description:"Benchmark doc."`
+	ID     int      `api:"path:id"`
+	Limit  int      `api:"query:limit" default:"10" validate:"min:1;max:100"`
+	Tags   []string `api:"query:tag"`
+	Tenant string   `api:"header:X-Tenant;required"`
+}
+
+type BenchmarkOutput struct {
+	ID     int    `json:"id"`
+	Tenant string `json:"tenant"`
+}
+
+func (a *BenchmarkAPI) GetItem(ctx context.Context, meta BenchmarkMetadata) (*BenchmarkOutput, error) {
+	return &BenchmarkOutput{ID: meta.ID, Tenant: meta.Tenant}, nil
+}
+
+func BenchmarkRestfulWrapper(b *testing.B) {
+	ctx := b.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().Register(ctx, "/v1", &BenchmarkAPI{})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/items/5?limit=20&tag=a&tag=b", nil)
+	req.Header.Set("X-Tenant", "tenant")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		recorder := httptest.NewRecorder()
+		container.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			b.Fatalf("unexpected status: %d", recorder.Code)
+		}
+	}
+}
+
+// serveWrapper serves the wrapper given until the test ends.
+func serveWrapper(t *testing.T, webService *restfulwrapper.RestfulWrapper) *httptest.Server {
+	t.Helper()
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	server := httptest.NewServer(container)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// doRequest performs a request against the server and returns the response along with its body.
+func doRequest(t *testing.T, server *httptest.Server, method string, path string, headers map[string]string, body io.Reader) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), method, server.URL+path, body)
+	require.Nil(t, err)
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp, string(bodyBytes)
+}
+
+func TestRestfulWrapperMaxBodyBytes(t *testing.T) {
+	ctx := t.Context()
+
+	type smallMetadata struct {
+		restfulwrapper.HTTPMethodPOST
+		_    string `api:"httppath:/small"`
+		Body string `api:"body:maxbytes:8"`
+	}
+	type defaultMetadata struct {
+		restfulwrapper.HTTPMethodPOST
+		_    string         `api:"httppath:/default"`
+		Body map[string]any `api:"body"`
+	}
+	type partsMetadata struct {
+		restfulwrapper.HTTPMethodPOST
+		_     string                            `api:"httppath:/parts"`
+		Parts iter.Seq2[*multipart.Part, error] `api:"body"`
+	}
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON, "text/plain").
+		Produces(restful.MIME_JSON).
+		MaxBodyBytes(32)
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta smallMetadata) (string, error) {
+		return meta.Body, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta defaultMetadata) (int, error) {
+		return len(meta.Body), nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta partsMetadata) (int64, error) {
+		var size int64
+		for part, err := range meta.Parts {
+			if err != nil {
+				return 0, err
+			}
+			n, err := io.Copy(io.Discard, part)
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+		return size, nil
+	})
+	server := serveWrapper(t, webService)
+
+	rows := []struct {
+		Description string
+		Path        string
+		ContentType string
+		Body        io.Reader
+		Code        int
+		Output      string
+	}{
+		{
+			Description: "Tag limit; small body",
+			Path:        "/api/v1/small",
+			ContentType: "text/plain",
+			Body:        strings.NewReader("12345678"),
+			Code:        http.StatusOK,
+			Output:      `"12345678"`,
+		},
+		{
+			Description: "Tag limit; large body",
+			Path:        "/api/v1/small",
+			ContentType: "text/plain",
+			Body:        strings.NewReader("123456789"),
+			Code:        http.StatusRequestEntityTooLarge,
+			Output:      `{"type":"*restfulwrapper.APIResponseError","message":"Request body is too large (maximum 8 bytes)."}`,
+		},
+		{
+			Description: "Tag limit; large body with unknown length",
+			Path:        "/api/v1/small",
+			ContentType: "text/plain",
+			Body:        io.MultiReader(strings.NewReader("123456789")),
+			Code:        http.StatusRequestEntityTooLarge,
+			Output:      `{"type":"*restfulwrapper.APIResponseError","message":"Request body is too large (maximum 8 bytes)."}`,
+		},
+		{
+			Description: "Wrapper limit; small body",
+			Path:        "/api/v1/default",
+			ContentType: restful.MIME_JSON,
+			Body:        strings.NewReader(`{"a":1,"b":2}`),
+			Code:        http.StatusOK,
+			Output:      `2`,
+		},
+		{
+			Description: "Wrapper limit; large body with unknown length",
+			Path:        "/api/v1/default",
+			ContentType: restful.MIME_JSON,
+			Body:        io.MultiReader(strings.NewReader(`{"a":"` + strings.Repeat("a", 100) + `"}`)),
+			Code:        http.StatusRequestEntityTooLarge,
+			Output:      `{"type":"*restfulwrapper.APIResponseError","message":"Request body is too large (maximum 32 bytes)."}`,
+		},
+		{
+			Description: "Wrapper limit; streaming multipart",
+			Path:        "/api/v1/parts",
+			ContentType: "multipart/form-data; boundary=boundary",
+			Body:        io.MultiReader(strings.NewReader("--boundary\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n" + strings.Repeat("a", 100) + "\r\n--boundary--\r\n")),
+			Code:        http.StatusRequestEntityTooLarge,
+			Output:      `{"type":"*restfulwrapper.APIResponseError","message":"Request body is too large (maximum 32 bytes)."}`,
+		},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			resp, body := doRequest(t, server, http.MethodPost, row.Path, map[string]string{"Content-Type": row.ContentType}, row.Body)
+			require.Equal(t, row.Code, resp.StatusCode)
+			assert.JSONEq(t, row.Output, body)
+		})
+	}
+}
+
+type NoContentAPI struct{}
+
+type NoContentOutput struct {
+	Value string `json:"value"`
+}
+
+type NoContentMetadata1 struct {
+	restfulwrapper.HTTPMethodDELETE
+	_ string `api:"httppath:/none"`
+}
+
+func (a *NoContentAPI) DeleteNone(ctx context.Context, meta NoContentMetadata1) error {
+	return nil
+}
+
+type NoContentMetadata2 struct {
+	restfulwrapper.HTTPMethodGET
+	_     string `api:"httppath:/pointer"`
+	Found bool   `api:"query:found"`
+}
+
+func (a *NoContentAPI) GetPointer(ctx context.Context, meta NoContentMetadata2) (*NoContentOutput, error) {
+	if !meta.Found {
+		return nil, nil
+	}
+	return &NoContentOutput{Value: "found"}, nil
+}
+
+type NoContentMetadata3 struct {
+	restfulwrapper.HTTPMethodPOST
+	_ string `api:"httppath:/status"`
+	_ string `api:"status:201"`
+}
+
+func (a *NoContentAPI) PostStatus(ctx context.Context, meta NoContentMetadata3) (*NoContentOutput, error) {
+	return nil, nil
+}
+
+type NoContentCreatedOutput struct {
+	Value string `json:"value"`
+}
+
+func (o NoContentCreatedOutput) StatusCode() int {
+	return http.StatusCreated
+}
+
+type NoContentMetadata4 struct {
+	restfulwrapper.HTTPMethodGET
+	_     string `api:"httppath:/created"`
+	Found bool   `api:"query:found"`
+}
+
+func (a *NoContentAPI) GetCreated(ctx context.Context, meta NoContentMetadata4) (*NoContentCreatedOutput, error) {
+	if !meta.Found {
+		return nil, nil
+	}
+	return &NoContentCreatedOutput{Value: "created"}, nil
+}
+
+func TestRestfulWrapperNoContent(t *testing.T) {
+	ctx := t.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().NoContent(true).Register(ctx, "/v1", &NoContentAPI{})
+	webService.Session().Register(ctx, "/v2", &NoContentAPI{})
+	server := serveWrapper(t, webService)
+
+	rows := []struct {
+		Description string
+		Method      string
+		Path        string
+		Code        int
+		Output      string
+	}{
+		{Description: "No output", Method: http.MethodDelete, Path: "/api/v1/none", Code: http.StatusNoContent, Output: ""},
+		{Description: "Nil pointer", Method: http.MethodGet, Path: "/api/v1/pointer", Code: http.StatusNoContent, Output: ""},
+		{Description: "Non-nil pointer", Method: http.MethodGet, Path: "/api/v1/pointer?found=true", Code: http.StatusOK, Output: `{"value":"found"}`},
+		{Description: "Status tag", Method: http.MethodPost, Path: "/api/v1/status", Code: http.StatusCreated, Output: "null"},
+		{Description: "Nil StatusCoder pointer", Method: http.MethodGet, Path: "/api/v1/created", Code: http.StatusNoContent, Output: ""},
+		{Description: "Non-nil StatusCoder pointer", Method: http.MethodGet, Path: "/api/v1/created?found=true", Code: http.StatusCreated, Output: `{"value":"created"}`},
+		{Description: "Disabled; no output", Method: http.MethodDelete, Path: "/api/v2/none", Code: http.StatusOK, Output: ""},
+		{Description: "Disabled; nil pointer", Method: http.MethodGet, Path: "/api/v2/pointer", Code: http.StatusOK, Output: "null"},
+		{Description: "Disabled; nil StatusCoder pointer", Method: http.MethodGet, Path: "/api/v2/created", Code: http.StatusOK, Output: "null"},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			resp, body := doRequest(t, server, row.Method, row.Path, map[string]string{"Content-Type": restful.MIME_JSON}, nil)
+			require.Equal(t, row.Code, resp.StatusCode)
+			if row.Code == http.StatusNoContent {
+				// There is no body, so there should be nothing that describes one.
+				assert.Empty(t, resp.Header.Get("Content-Type"))
+				assert.Empty(t, resp.Header.Get("Content-Length"))
+			}
+			if row.Output == "" {
+				assert.Equal(t, "", body)
+			} else {
+				assert.JSONEq(t, row.Output, body)
+			}
+		})
+	}
+	t.Run("Documentation", func(t *testing.T) {
+		for _, route := range webService.WebService().Routes() {
+			switch route.Path {
+			case "/api/v1/none", "/api/v1/pointer", "/api/v1/created":
+				assert.Contains(t, route.ResponseErrors, http.StatusNoContent, route.Path)
+			default:
+				assert.NotContains(t, route.ResponseErrors, http.StatusNoContent, route.Path)
+			}
+		}
+	})
+}
+
+type StreamItem struct {
+	Index int `json:"index"`
+}
+
+func TestRestfulWrapperStream(t *testing.T) {
+	ctx := t.Context()
+
+	type readerMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/reader"`
+		_ string `api:"produces:text/plain"`
+	}
+	type seqMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/seq"`
+		_ string `api:"produces:application/json"`
+		_ string `api:"produces:application/x-ndjson"`
+	}
+	type channelMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/channel"`
+	}
+	type endlessMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/endless"`
+		_ string `api:"produces:application/x-ndjson"`
+	}
+
+	endlessDone := make(chan struct{}) // This is closed when the endless stream stops.
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta readerMetadata) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("line 1\nline 2\n")), nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta seqMetadata) (iter.Seq[StreamItem], error) {
+		return func(yield func(StreamItem) bool) {
+			for i := range 3 {
+				if !yield(StreamItem{Index: i}) {
+					return
+				}
+			}
+		}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta channelMetadata) (<-chan StreamItem, error) {
+		items := make(chan StreamItem)
+		go func() {
+			defer close(items)
+			for i := range 2 {
+				select {
+				case items <- StreamItem{Index: i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return items, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta endlessMetadata) (iter.Seq[StreamItem], error) {
+		return func(yield func(StreamItem) bool) {
+			defer close(endlessDone)
+			for i := 0; ; i++ {
+				if !yield(StreamItem{Index: i}) {
+					return
+				}
+			}
+		}, nil
+	})
+	server := serveWrapper(t, webService)
+
+	rows := []struct {
+		Description string
+		Path        string
+		Accept      string
+		ContentType string
+		Output      string
+	}{
+		{Description: "Reader", Path: "/api/v1/reader", ContentType: "text/plain", Output: "line 1\nline 2\n"},
+		{Description: "Seq as JSON", Path: "/api/v1/seq", ContentType: restful.MIME_JSON, Output: "[{\"index\":0}\n,{\"index\":1}\n,{\"index\":2}\n]"},
+		{Description: "Seq as NDJSON", Path: "/api/v1/seq", Accept: restfulwrapper.MIME_NDJSON, ContentType: restfulwrapper.MIME_NDJSON, Output: "{\"index\":0}\n{\"index\":1}\n{\"index\":2}\n"},
+		{Description: "Channel", Path: "/api/v1/channel", ContentType: restful.MIME_JSON, Output: "[{\"index\":0}\n,{\"index\":1}\n]"},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			headers := map[string]string{}
+			if row.Accept != "" {
+				headers["Accept"] = row.Accept
+			}
+
+			resp, body := doRequest(t, server, http.MethodGet, row.Path, headers, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, row.ContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, row.Output, body)
+		})
+	}
+	t.Run("Cancel", func(t *testing.T) {
+		requestCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, server.URL+"/api/v1/endless", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var item StreamItem
+		err = json.NewDecoder(resp.Body).Decode(&item)
+		require.Nil(t, err)
+		assert.Equal(t, 0, item.Index)
+
+		cancel()
+		select {
+		case <-endlessDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the stream did not stop")
+		}
+	})
+	t.Run("Documentation", func(t *testing.T) {
+		for _, route := range webService.Routes() {
+			switch route.HTTPPath {
+			case "/api/v1/reader":
+				assert.Nil(t, route.ResponseExample)
+			default:
+				assert.IsType(t, &[]StreamItem{}, route.ResponseExample)
+			}
+		}
+	})
+}
+
+// readServerSentEventFrame reads the next frame of a Server-Sent Events stream, up to and including the blank line that ends it.
+func readServerSentEventFrame(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	var frame strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err)
+		frame.WriteString(line)
+		if line == "\n" {
+			return frame.String()
+		}
+	}
+}
+
+func TestRestfulWrapperServerSentEvents(t *testing.T) {
+	ctx := t.Context()
+
+	type eventsMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_           string `api:"httppath:/events"`
+		LastEventID string `api:"header:Last-Event-ID" description:"The ID of the last event that was received."`
+	}
+	type messagesMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/messages"`
+	}
+	type waitingMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/waiting"`
+	}
+	type endlessMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/endless"`
+	}
+	type endlessChannelMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/endless-channel"`
+	}
+	type brokenMetadata struct {
+		restfulwrapper.HTTPMethodGET
+		_ string `api:"httppath:/broken"`
+	}
+	type panicReport struct {
+		info *restfulwrapper.RestfulFunctionInfo
+		err  *restfulwrapper.PanicError
+	}
+
+	release := make(chan struct{})            // This is closed to let the waiting stream send its event.
+	endlessDone := make(chan struct{})        // This is closed when the endless stream stops.
+	endlessChannelDone := make(chan struct{}) // This is closed when the endless channel stops.
+	panics := make(chan panicReport, 1)
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON).
+		ServerSentEventKeepAlive(10 * time.Millisecond).
+		OnPanic(func(ctx context.Context, info *restfulwrapper.RestfulFunctionInfo, err *restfulwrapper.PanicError) {
+			panics <- panicReport{info: info, err: err}
+		})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta eventsMetadata) (iter.Seq[restfulwrapper.ServerSentEvent], error) {
+		// This sends the events after the last one that the client received.
+		messages := []string{"a", "b", "c"}
+		start := 0
+		if meta.LastEventID != "" {
+			lastEventID, err := strconv.Atoi(meta.LastEventID)
+			if err != nil {
+				return nil, restfulwrapper.NewAPIHeaderParameterError("Last-Event-ID", err)
+			}
+			start = lastEventID
+		}
+		return func(yield func(restfulwrapper.ServerSentEvent) bool) {
+			for i := start; i < len(messages); i++ {
+				event := restfulwrapper.ServerSentEvent{
+					ID:   fmt.Sprintf("%d", i+1),
+					Data: messages[i],
+				}
+				if !yield(event) {
+					return
+				}
+			}
+		}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta messagesMetadata) (<-chan restfulwrapper.ServerSentEvent, error) {
+		events := make(chan restfulwrapper.ServerSentEvent, 1)
+		events <- restfulwrapper.ServerSentEvent{Data: "hello"}
+		close(events)
+		return events, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta waitingMetadata) (iter.Seq[restfulwrapper.ServerSentEvent], error) {
+		return func(yield func(restfulwrapper.ServerSentEvent) bool) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return
+			}
+			yield(restfulwrapper.ServerSentEvent{
+				ID:    "1",
+				Event: "progress",
+				Data:  map[string]int{"percent": 100},
+			})
+		}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta endlessMetadata) (iter.Seq[restfulwrapper.ServerSentEvent], error) {
+		return func(yield func(restfulwrapper.ServerSentEvent) bool) {
+			defer close(endlessDone)
+			for i := 1; ; i++ {
+				if !yield(restfulwrapper.ServerSentEvent{ID: fmt.Sprintf("%d", i)}) {
+					return
+				}
+			}
+		}, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta endlessChannelMetadata) (<-chan restfulwrapper.ServerSentEvent, error) {
+		events := make(chan restfulwrapper.ServerSentEvent)
+		go func() {
+			defer close(endlessChannelDone)
+			defer close(events)
+			for i := 1; ; i++ {
+				select {
+				case events <- restfulwrapper.ServerSentEvent{ID: fmt.Sprintf("%d", i)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return events, nil
+	})
+	restfulwrapper.Handle(ctx, webService, "/v1", func(ctx context.Context, meta brokenMetadata) (iter.Seq[restfulwrapper.ServerSentEvent], error) {
+		return func(yield func(restfulwrapper.ServerSentEvent) bool) {
+			if !yield(restfulwrapper.ServerSentEvent{Data: "first"}) {
+				return
+			}
+			panic("boom in iterator")
+		}, nil
+	})
+	server := serveWrapper(t, webService)
+
+	eventStreamHeaders := map[string]string{"Accept": restfulwrapper.MIME_EVENT_STREAM}
+
+	t.Run("Documentation", func(t *testing.T) {
+		for _, route := range webService.Routes() {
+			assert.Equal(t, []string{restfulwrapper.MIME_EVENT_STREAM}, route.Produces)
+			assert.Nil(t, route.ResponseExample)
+			if route.HTTPPath == "/api/v1/events" && assert.Equal(t, 1, len(route.HeaderParameters)) {
+				assert.Equal(t, "Last-Event-ID", route.HeaderParameters[0].Name)
+			}
+		}
+	})
+	t.Run("Resume", func(t *testing.T) {
+		rows := []struct {
+			Description string
+			LastEventID string
+			Output      string
+		}{
+			{
+				Description: "No Last-Event-ID",
+				Output:      "id: 1\ndata: a\n\nid: 2\ndata: b\n\nid: 3\ndata: c\n\n",
+			},
+			{
+				Description: "After the first event",
+				LastEventID: "1",
+				Output:      "id: 2\ndata: b\n\nid: 3\ndata: c\n\n",
+			},
+			{
+				Description: "After the last event",
+				LastEventID: "3",
+				Output:      "",
+			},
+		}
+		for rowIndex, row := range rows {
+			t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+				headers := map[string]string{"Accept": restfulwrapper.MIME_EVENT_STREAM}
+				if row.LastEventID != "" {
+					headers["Last-Event-ID"] = row.LastEventID
+				}
+
+				resp, body := doRequest(t, server, http.MethodGet, "/api/v1/events", headers, nil)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, restfulwrapper.MIME_EVENT_STREAM, resp.Header.Get("Content-Type"))
+				assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+				// The events are sent without delay, but a keep-alive could still sneak in.
+				body = strings.ReplaceAll(body, ": keep-alive\n\n", "")
+				assert.Equal(t, row.Output, body)
+			})
+		}
+	})
+	t.Run("Channel", func(t *testing.T) {
+		resp, body := doRequest(t, server, http.MethodGet, "/api/v1/messages", eventStreamHeaders, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "data: hello\n\n", strings.ReplaceAll(body, ": keep-alive\n\n", ""))
+	})
+	t.Run("Keep-alive", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/waiting", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Nothing is sent until the stream is released, so there should only be keep-alives until then.
+		reader := bufio.NewReader(resp.Body)
+		for range 3 {
+			assert.Equal(t, ": keep-alive\n\n", readServerSentEventFrame(t, reader))
+		}
+		close(release)
+
+		frame := readServerSentEventFrame(t, reader)
+		for frame == ": keep-alive\n\n" {
+			frame = readServerSentEventFrame(t, reader)
+		}
+		assert.Equal(t, "id: 1\nevent: progress\ndata: {\"percent\":100}\n\n", frame)
+
+		// The stream ends after the event.
+		remaining, err := io.ReadAll(reader)
+		require.Nil(t, err)
+		assert.Equal(t, "", strings.ReplaceAll(string(remaining), ": keep-alive\n\n", ""))
+	})
+	t.Run("Cancel", func(t *testing.T) {
+		rows := []struct {
+			Description string
+			Path        string
+			Done        <-chan struct{}
+		}{
+			{Description: "Iterator", Path: "/api/v1/endless", Done: endlessDone},
+			{Description: "Channel", Path: "/api/v1/endless-channel", Done: endlessChannelDone},
+		}
+		for rowIndex, row := range rows {
+			t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+				requestCtx, cancel := context.WithCancel(ctx)
+				defer cancel()
+
+				req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, server.URL+row.Path, nil)
+				require.Nil(t, err)
+
+				resp, err := http.DefaultClient.Do(req)
+				require.Nil(t, err)
+				defer resp.Body.Close()
+
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				reader := bufio.NewReader(resp.Body)
+				frame := readServerSentEventFrame(t, reader)
+				for frame == ": keep-alive\n\n" {
+					frame = readServerSentEventFrame(t, reader)
+				}
+				assert.Equal(t, "id: 1\n\n", frame)
+
+				cancel()
+				select {
+				case <-row.Done:
+				case <-time.After(5 * time.Second):
+					t.Fatal("the stream did not stop")
+				}
+			})
+		}
+	})
+	t.Run("Panic", func(t *testing.T) {
+		resp, body := doRequest(t, server, http.MethodGet, "/api/v1/broken", eventStreamHeaders, nil)
+
+		// The stream had already started, so the panic only reaches the hook.
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "data: first\n\n", strings.ReplaceAll(body, ": keep-alive\n\n", ""))
+
+		select {
+		case report := <-panics:
+			assert.Equal(t, "boom in iterator", report.err.Value)
+			assert.Contains(t, string(report.err.Stack), "TestRestfulWrapperServerSentEvents")
+			assert.Equal(t, http.MethodGet, report.info.HTTPMethod)
+			assert.Equal(t, "/api/v1/broken", report.info.HTTPPath)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the panic was not reported")
+		}
+	})
+}
+
+type ProblemDetailsAPI struct{}
+
+type ProblemDetailsInput struct {
+	Name string `json:"name"`
+}
+
+type ProblemDetailsMetadata1 struct {
+	restfulwrapper.HTTPMethodGET
+	_     string `api:"httppath:/items/{id}"`
+	ID    int    `api:"path:id"`
+	Limit int    `api:"query:limit" validate:"max:10"`
+}
+
+func (a *ProblemDetailsAPI) GetItem(ctx context.Context, meta ProblemDetailsMetadata1) (*ProblemDetailsInput, error) {
+	switch meta.ID {
+	case 404:
+		return nil, restfulwrapper.NewAPIResponseError(http.StatusNotFound, "Item not found.")
+	case 500:
+		return nil, fmt.Errorf("database is down")
+	case 503:
+		return nil, ProblemDetailsCustomError{}
+	}
+	return &ProblemDetailsInput{Name: "item"}, nil
+}
+
+type ProblemDetailsMetadata2 struct {
+	restfulwrapper.HTTPMethodPOST
+	_    string              `api:"httppath:/items"`
+	Body ProblemDetailsInput `api:"body"`
+}
+
+func (a *ProblemDetailsAPI) PostItem(ctx context.Context, meta ProblemDetailsMetadata2) (*ProblemDetailsInput, error) {
+	return &meta.Body, nil
+}
+
+// ProblemDetailsCustomError is an ErrorWriter that is not one of the built-in errors.
+type ProblemDetailsCustomError struct{}
+
+func (e ProblemDetailsCustomError) Error() string {
+	return "custom"
+}
+
+func (e ProblemDetailsCustomError) WriteError(resp *restful.Response) {
+	resp.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = io.WriteString(resp, "custom")
+}
+
+func TestRestfulWrapperProblemDetails(t *testing.T) {
+	ctx := t.Context()
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().ProblemDetails(true).Register(ctx, "/v1", &ProblemDetailsAPI{})
+	webService.Session().Register(ctx, "/v2", &ProblemDetailsAPI{})
+	server := serveWrapper(t, webService)
+
+	rows := []struct {
+		Description string
+		Method      string
+		Path        string
+		Input       string
+		Code        int
+		ContentType string
+		Output      string
+	}{
+		{Description: "Success", Method: http.MethodGet, Path: "/api/v1/items/1", Code: http.StatusOK, ContentType: restful.MIME_JSON, Output: `{"name":"item"}`},
+		{Description: "Response error", Method: http.MethodGet, Path: "/api/v1/items/404", Code: http.StatusNotFound, ContentType: restfulwrapper.MIME_PROBLEM_JSON, Output: `{"title":"Not Found","status":404,"detail":"Item not found.","instance":"/api/v1/items/404"}`},
+		{Description: "Generic error", Method: http.MethodGet, Path: "/api/v1/items/500", Code: http.StatusInternalServerError, ContentType: restfulwrapper.MIME_PROBLEM_JSON, Output: `{"title":"Internal Server Error","status":500,"detail":"database is down","instance":"/api/v1/items/500"}`},
+		{Description: "Custom error", Method: http.MethodGet, Path: "/api/v1/items/503", Code: http.StatusServiceUnavailable, Output: "custom"},
+		{Description: "Path parameter error", Method: http.MethodGet, Path: "/api/v1/items/bogus", Code: http.StatusBadRequest, ContentType: restfulwrapper.MIME_PROBLEM_JSON, Output: `{"title":"Bad Request","status":400,"detail":"strconv.ParseInt: parsing \"bogus\": invalid syntax","instance":"/api/v1/items/bogus","parameter":"id","in":"path"}`},
+		{Description: "Query parameter error", Method: http.MethodGet, Path: "/api/v1/items/1?limit=11", Code: http.StatusBadRequest, ContentType: restfulwrapper.MIME_PROBLEM_JSON, Output: `{"title":"Bad Request","status":400,"detail":"must be at most 10","instance":"/api/v1/items/1","parameter":"limit","in":"query"}`},
+		{Description: "Body error", Method: http.MethodPost, Path: "/api/v1/items", Input: `{`, Code: http.StatusBadRequest, ContentType: restfulwrapper.MIME_PROBLEM_JSON, Output: `{"title":"Bad Request","status":400,"detail":"could not read request body (entity): unexpected EOF","instance":"/api/v1/items","in":"body"}`},
+		{Description: "Disabled; response error", Method: http.MethodGet, Path: "/api/v2/items/404", Code: http.StatusNotFound, ContentType: restful.MIME_JSON, Output: `{"type":"*restfulwrapper.APIResponseError","message":"Item not found."}`},
+		{Description: "Disabled; generic error", Method: http.MethodGet, Path: "/api/v2/items/500", Code: http.StatusInternalServerError, ContentType: restful.MIME_JSON, Output: `{"type":"*errors.errorString","message":"database is down"}`},
+	}
+	for rowIndex, row := range rows {
+		t.Run(fmt.Sprintf("%d/%s", rowIndex, row.Description), func(t *testing.T) {
+			resp, body := doRequest(t, server, row.Method, row.Path, map[string]string{"Content-Type": restful.MIME_JSON}, strings.NewReader(row.Input))
+			require.Equal(t, row.Code, resp.StatusCode)
+			if row.ContentType == "" {
+				assert.Equal(t, row.Output, body)
+			} else {
+				assert.Equal(t, row.ContentType, resp.Header.Get("Content-Type"))
+				assert.JSONEq(t, row.Output, body)
+			}
+		})
+	}
+	t.Run("OpenAPI", func(t *testing.T) {
+		document := webService.OpenAPI(restfulwrapper.OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+
+		operation := document.Paths["/api/v1/items/{id}"]["get"]
+		require.NotNil(t, operation)
+		for _, code := range []string{"400", "500"} {
+			require.Contains(t, operation.Responses, code)
+			assert.Equal(t, restfulwrapper.JSONSchema{"$ref": "#/components/schemas/ProblemDetailsOutput"}, operation.Responses[code].Content[restfulwrapper.MIME_PROBLEM_JSON].Schema, code)
+		}
+
+		operation = document.Paths["/api/v2/items/{id}"]["get"]
+		require.NotNil(t, operation)
+		assert.Contains(t, operation.Responses["400"].Content, restful.MIME_JSON)
+	})
+}
+
+func TestRestfulWrapperHideInternalErrors(t *testing.T) {
+	ctx := t.Context()
+
+	// Capture the logs so that we can find the error IDs in them.
+	var logs bytes.Buffer
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previousLogger) })
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Session().HideInternalErrors(true).Register(ctx, "/v1", &ProblemDetailsAPI{})
+	webService.Session().HideInternalErrors(true).ProblemDetails(true).Register(ctx, "/v2", &ProblemDetailsAPI{})
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	get := func(t *testing.T, path string) (*http.Response, map[string]any) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		var output map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		require.Nil(t, err)
+		return resp, output
+	}
+
+	t.Run("Internal error", func(t *testing.T) {
+		resp, output := get(t, "/api/v1/items/500")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+		errorID := resp.Header.Get(restfulwrapper.ErrorIDHeader)
+		require.NotEmpty(t, errorID)
+		assert.Equal(t, map[string]any{"message": "An internal error occurred.", "errorId": errorID}, output)
+		assert.Contains(t, logs.String(), "errorId="+errorID)
+		assert.Contains(t, logs.String(), "database is down")
+	})
+	t.Run("Internal error; problem details", func(t *testing.T) {
+		resp, output := get(t, "/api/v2/items/500")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+		errorID := resp.Header.Get(restfulwrapper.ErrorIDHeader)
+		require.NotEmpty(t, errorID)
+		assert.Equal(t, errorID, output["errorId"])
+		assert.Equal(t, "An internal error occurred.", output["detail"])
+	})
+	t.Run("Unique error IDs", func(t *testing.T) {
+		resp1, _ := get(t, "/api/v1/items/500")
+		resp2, _ := get(t, "/api/v1/items/500")
+		assert.NotEqual(t, resp1.Header.Get(restfulwrapper.ErrorIDHeader), resp2.Header.Get(restfulwrapper.ErrorIDHeader))
+	})
+	t.Run("Response error", func(t *testing.T) {
+		resp, output := get(t, "/api/v1/items/404")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		assert.Empty(t, resp.Header.Get(restfulwrapper.ErrorIDHeader))
+		assert.Equal(t, "Item not found.", output["message"])
+	})
+}
+
+type PanicAPI struct{}
+
+type PanicMetadata1 struct {
+	restfulwrapper.HTTPMethodGET
+	_ string `api:"httppath:/panic"`
+}
+
+func (a *PanicAPI) GetPanic(ctx context.Context, meta PanicMetadata1) (string, error) {
+	var values map[string]int
+	values["boom"] = 1 // This panics, since the map is nil.
+	return "", nil
+}
+
+type PanicMetadata2 struct {
+	restfulwrapper.HTTPMethodGET
+	_ string `api:"httppath:/panic-value"`
+}
+
+func (a *PanicAPI) GetPanicValue(ctx context.Context, meta PanicMetadata2) (string, error) {
+	panic(&PanicValue{Reason: "custom"})
+}
+
+type PanicMetadata3 struct {
+	restfulwrapper.HTTPMethodGET
+	_ string `api:"httppath:/panic-stream"`
+}
+
+func (a *PanicAPI) GetPanicStream(ctx context.Context, meta PanicMetadata3) (iter.Seq[string], error) {
+	return func(yield func(string) bool) {
+		if !yield("a") {
+			return
+		}
+		panic("stream")
+	}, nil
+}
+
+// PanicValue is a custom panic value.
+type PanicValue struct {
+	Reason string
+}
+
+func TestRestfulWrapperPanic(t *testing.T) {
+	ctx := t.Context()
+
+	type handleMetadata struct {
+		restfulwrapper.HTTPMethodPOST
+		_ string `api:"httppath:/panic-handle"`
+	}
+	type panicReport struct {
+		info *restfulwrapper.RestfulFunctionInfo
+		err  *restfulwrapper.PanicError
+	}
+
+	panics := make(chan panicReport, 10)
+
+	webService := restfulwrapper.WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	session := webService.Session().
+		OnPanic(func(ctx context.Context, info *restfulwrapper.RestfulFunctionInfo, err *restfulwrapper.PanicError) {
+			panics <- panicReport{info: info, err: err}
+		})
+	session.Register(ctx, "/v1", &PanicAPI{})
+	restfulwrapper.Handle(ctx, session, "/v1", func(ctx context.Context, meta handleMetadata) (string, error) {
+		panic("handle")
+	})
+	webService.Session().
+		ErrorHandler(func(err error) error {
+			var panicErr *restfulwrapper.PanicError
+			if errors.As(err, &panicErr) {
+				if value, ok := panicErr.Value.(*PanicValue); ok {
+					return restfulwrapper.NewAPIResponseError(http.StatusServiceUnavailable, value.Reason)
+				}
+			}
+			return err
+		}).
+		HideInternalErrors(true).
+		Register(ctx, "/v2", &PanicAPI{})
+	server := serveWrapper(t, webService)
+
+	do := func(t *testing.T, method string, path string) (*http.Response, map[string]any) {
+		resp, body := doRequest(t, server, method, path, map[string]string{"Content-Type": restful.MIME_JSON}, nil)
+
+		var output map[string]any
+		err := json.Unmarshal([]byte(body), &output)
+		require.Nil(t, err)
+		return resp, output
+	}
+	nextPanic := func(t *testing.T) panicReport {
+		select {
+		case report := <-panics:
+			return report
+		case <-time.After(5 * time.Second):
+			t.Fatal("the panic was not reported")
+			return panicReport{}
+		}
+	}
+
+	t.Run("Panic", func(t *testing.T) {
+		resp, output := do(t, http.MethodGet, "/api/v1/panic")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, `*restfulwrapper.PanicError`, output["type"])
+		assert.Equal(t, "panic: assignment to entry in nil map", output["message"])
+
+		report := nextPanic(t)
+		assert.Contains(t, string(report.err.Stack), "GetPanic")
+		var runtimeErr interface{ RuntimeError() }
+		assert.ErrorAs(t, report.err, &runtimeErr)
+		assert.Equal(t, http.MethodGet, report.info.HTTPMethod)
+		assert.Equal(t, "/api/v1/panic", report.info.HTTPPath)
+		assert.Equal(t, "GetPanic", report.info.MethodName)
+	})
+	t.Run("Panic value", func(t *testing.T) {
+		resp, output := do(t, http.MethodGet, "/api/v1/panic-value")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, "panic: &{custom}", output["message"])
+
+		report := nextPanic(t)
+		assert.Equal(t, &PanicValue{Reason: "custom"}, report.err.Value)
+		assert.Equal(t, "/api/v1/panic-value", report.info.HTTPPath)
+	})
+	t.Run("Handle", func(t *testing.T) {
+		resp, output := do(t, http.MethodPost, "/api/v1/panic-handle")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, "panic: handle", output["message"])
+
+		report := nextPanic(t)
+		assert.Equal(t, "handle", report.err.Value)
+		assert.Equal(t, http.MethodPost, report.info.HTTPMethod)
+		assert.Equal(t, "/api/v1/panic-handle", report.info.HTTPPath)
+	})
+	t.Run("Error handler", func(t *testing.T) {
+		resp, output := do(t, http.MethodGet, "/api/v2/panic-value")
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "custom", output["message"])
+	})
+	t.Run("Hidden", func(t *testing.T) {
+		resp, output := do(t, http.MethodGet, "/api/v2/panic")
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get(restfulwrapper.ErrorIDHeader))
+		assert.Equal(t, "An internal error occurred.", output["message"])
+
+		assert.Equal(t, 0, len(panics), "the hook only applies to its own session")
+	})
+	t.Run("Stream", func(t *testing.T) {
+		resp, body := doRequest(t, server, http.MethodGet, "/api/v1/panic-stream", nil, nil)
+
+		// The response had already started, so the panic cannot change it.
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotContains(t, body, "panic")
+
+		report := nextPanic(t)
+		assert.Equal(t, "stream", report.err.Value)
+		assert.Equal(t, "/api/v1/panic-stream", report.info.HTTPPath)
+		assert.Equal(t, "GetPanicStream", report.info.MethodName)
+	})
 }
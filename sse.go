@@ -0,0 +1,184 @@
+package restfulwrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_EVENT_STREAM is the content type for Server-Sent Events.
+const MIME_EVENT_STREAM = "text/event-stream"
+
+// defaultServerSentEventKeepAlive is how often a keep-alive comment is sent when there are no events.
+const defaultServerSentEventKeepAlive = 15 * time.Second
+
+// ServerSentEvent is a single Server-Sent Event.
+//
+// A method that returns an "iter.Seq[ServerSentEvent]" or a "<-chan ServerSentEvent" is a Server-Sent Events
+// endpoint: it produces "text/event-stream", and each event is written (and flushed) as it is received.  Keep-alive
+// comments are sent while there are no events, and the stream stops when the request's context is canceled.
+//
+// To resume a stream, add a `api:"header:Last-Event-ID"` field to the metadata; the browser sends the ID of the
+// last event that it received when it reconnects.
+type ServerSentEvent struct {
+	ID    string        // This is the event ID ("id"), if any.
+	Event string        // This is the event type ("event"), if any; browsers default to "message".
+	Data  any           // This is the event data ("data"); strings and byte slices are sent as-is, and anything else as JSON.
+	Retry time.Duration // This is the reconnection time ("retry"), if any.
+}
+
+var (
+	serverSentEventType         = reflect.TypeOf(ServerSentEvent{})
+	serverSentEventSeqType      = reflect.TypeOf(iter.Seq[ServerSentEvent](nil))
+	serverSentEventChannelType  = reflect.TypeOf((<-chan ServerSentEvent)(nil))
+	serverSentEventKeepAliveMsg = []byte(": keep-alive\n\n")
+)
+
+// writeServerSentEvents writes the events of an "iter.Seq" or channel output to the response as Server-Sent Events.
+//
+// Once the response has started, any error is logged instead of returned, since the status has already been written.
+// A panic in an "iter.Seq" is raised again on the caller's goroutine.
+func (info *RestfulFunctionInfo) writeServerSentEvents(req *restful.Request, resp *restful.Response, status int, output any) error {
+	ctx := req.Request.Context()
+
+	outputValue := reflect.ValueOf(output)
+
+	var events <-chan ServerSentEvent
+	seqPanic := make(chan *PanicError, 1)
+	switch info.outputStream {
+	case streamKindSeq:
+		seq := outputValue.Convert(serverSentEventSeqType).Interface().(iter.Seq[ServerSentEvent])
+
+		// Run the iterator on its own, so that we can send keep-alives while it is waiting for the next event.
+		seqEvents := make(chan ServerSentEvent)
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			defer close(seqEvents)
+			// A panic here would take down the whole process, so hand it to the handler's goroutine instead.
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					seqPanic <- &PanicError{
+						Value: recovered,
+						Stack: debug.Stack(),
+					}
+				}
+			}()
+			if seq == nil {
+				return
+			}
+			seq(func(event ServerSentEvent) bool {
+				select {
+				case seqEvents <- event:
+					return true
+				case <-stop:
+					return false
+				}
+			})
+		}()
+		events = seqEvents
+	case streamKindChannel:
+		events = outputValue.Convert(serverSentEventChannelType).Interface().(<-chan ServerSentEvent)
+	}
+
+	resp.Header().Set("Content-Type", MIME_EVENT_STREAM)
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.WriteHeader(status)
+	resp.Flush()
+	markResponseCommitted(req)
+
+	keepAlive := info.ServerSentEventKeepAlive
+	if keepAlive == 0 {
+		keepAlive = defaultServerSentEventKeepAlive
+	}
+	var keepAliveTicks <-chan time.Time
+	if keepAlive > 0 {
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+		keepAliveTicks = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAliveTicks:
+			_, err = resp.Write(serverSentEventKeepAliveMsg)
+		case event, ok := <-events:
+			if !ok {
+				select {
+				case panicErr := <-seqPanic:
+					// This is recovered (and reported) like a panic in the method itself.
+					panic(panicErr)
+				default:
+				}
+				return nil
+			}
+			err = writeServerSentEvent(resp, event)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, fmt.Sprintf("Could not write server-sent event: %v", err))
+			return nil
+		}
+		resp.Flush()
+	}
+}
+
+// writeServerSentEvent writes a single event frame.
+func writeServerSentEvent(w io.Writer, event ServerSentEvent) error {
+	var data string
+	switch value := event.Data.(type) {
+	case nil:
+	case string:
+		data = value
+	case []byte:
+		data = string(value)
+	default:
+		contents, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("could not encode event data: %w", err)
+		}
+		data = string(contents)
+	}
+
+	var builder strings.Builder
+	if event.ID != "" {
+		builder.WriteString("id: " + serverSentEventField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		builder.WriteString("event: " + serverSentEventField(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		builder.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	if event.Data != nil {
+		// Each line of the data needs its own field; the browser joins them back together with newlines.
+		for line := range strings.SplitSeq(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(data), "\n") {
+			builder.WriteString("data: " + line + "\n")
+		}
+	}
+	builder.WriteString("\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// serverSentEventField removes any line breaks from a single-line field, since they would end the field early.
+func serverSentEventField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// isServerSentEventStream returns true if the streaming output is a stream of Server-Sent Events.
+func (info *RestfulFunctionInfo) isServerSentEventStream() bool {
+	return (info.outputStream == streamKindSeq || info.outputStream == streamKindChannel) && info.outputStreamItem == serverSentEventType
+}
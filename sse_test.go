@@ -0,0 +1,34 @@
+package restfulwrapper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteServerSentEvent(t *testing.T) {
+	rows := []struct {
+		Description string
+		Input       ServerSentEvent
+		Output      string
+	}{
+		{Description: "empty", Input: ServerSentEvent{}, Output: "\n"},
+		{Description: "string", Input: ServerSentEvent{Data: "hello"}, Output: "data: hello\n\n"},
+		{Description: "multiline", Input: ServerSentEvent{Data: "a\r\nb\nc"}, Output: "data: a\ndata: b\ndata: c\n\n"},
+		{Description: "bytes", Input: ServerSentEvent{Data: []byte("hello")}, Output: "data: hello\n\n"},
+		{Description: "json", Input: ServerSentEvent{Data: map[string]int{"a": 1}}, Output: "data: {\"a\":1}\n\n"},
+		{Description: "all fields", Input: ServerSentEvent{ID: "1", Event: "progress", Data: 50, Retry: 3 * time.Second}, Output: "id: 1\nevent: progress\nretry: 3000\ndata: 50\n\n"},
+		{Description: "line breaks in fields", Input: ServerSentEvent{ID: "1\n2", Event: "a\rb"}, Output: "id: 12\nevent: ab\n\n"},
+	}
+	for _, row := range rows {
+		t.Run(row.Description, func(t *testing.T) {
+			var builder strings.Builder
+			err := writeServerSentEvent(&builder, row.Input)
+			require.Nil(t, err)
+			assert.Equal(t, row.Output, builder.String())
+		})
+	}
+}
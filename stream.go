@@ -0,0 +1,224 @@
+package restfulwrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// MIME_NDJSON is the content type for newline-delimited JSON, which can be used to stream the items
+// of an "iter.Seq" or channel output one per line.
+const MIME_NDJSON = "application/x-ndjson"
+
+// streamKind is the kind of streaming output that a method has, if any.
+type streamKind int
+
+const (
+	streamKindNone    streamKind = iota // The output is not streamed.
+	streamKindReader                    // The output is an "io.Reader"; its bytes are streamed as-is.
+	streamKindSeq                       // The output is an "iter.Seq[T]"; its items are streamed as JSON.
+	streamKindChannel                   // The output is a "<-chan T" (or "chan T"); its items are streamed as JSON.
+)
+
+var (
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	writerType = reflect.TypeOf((*Writer)(nil)).Elem()
+)
+
+// streamBufferSize is the size of the buffer used to copy an "io.Reader" output.
+const streamBufferSize = 32 * 1024
+
+// parseStreamKind returns the kind of streaming output for the output type, along with the type of its
+// items (for "iter.Seq" and channel outputs).
+func parseStreamKind(outputType reflect.Type) (streamKind, reflect.Type) {
+	switch {
+	case outputType.Implements(writerType):
+		// A Writer always writes itself.
+		return streamKindNone, nil
+	case outputType.Implements(readerType):
+		return streamKindReader, nil
+	case outputType.Kind() == reflect.Chan && outputType.ChanDir()&reflect.RecvDir != 0:
+		return streamKindChannel, outputType.Elem()
+	case outputType.Kind() == reflect.Func && outputType.NumIn() == 1 && outputType.NumOut() == 0:
+		yieldType := outputType.In(0)
+		if yieldType.Kind() == reflect.Func && yieldType.NumIn() == 1 && yieldType.NumOut() == 1 && yieldType.Out(0).Kind() == reflect.Bool {
+			return streamKindSeq, yieldType.In(0)
+		}
+	}
+	return streamKindNone, nil
+}
+
+// writeStream writes a streaming output to the response, flushing as it goes.
+//
+// An "io.Reader" is copied as-is (and closed, if it is an "io.Closer"); its content type is negotiated from the
+// route's Produces (or is "application/octet-stream" if the route does not produce anything).  The items of an
+// "iter.Seq" or channel are written as NDJSON if that is the negotiated content type, or as a JSON array otherwise.
+//
+// Streams of ServerSentEvent items are written as Server-Sent Events instead.
+//
+// If nothing is acceptable, then this returns a 406 error without writing anything.  Once the response has started,
+// any error is logged instead, since the status has already been written.  Streaming stops early if the request's
+// context is canceled; a channel's sender should also watch the context so that it does not block forever.
+func (info *RestfulFunctionInfo) writeStream(req *restful.Request, resp *restful.Response, status int, output any) error {
+	ctx := req.Request.Context()
+
+	if info.isServerSentEventStream() {
+		return info.writeServerSentEvents(req, resp, status, output)
+	}
+
+	if closer, ok := output.(io.Closer); ok && info.outputStream == streamKindReader {
+		defer closer.Close()
+	}
+
+	var contentType string
+	if info.outputStream == streamKindReader {
+		contentType = "application/octet-stream"
+		if len(info.Produces) > 0 {
+			var ok bool
+			contentType, ok = negotiateContentType(req.Request.Header.Get("Accept"), info.Produces)
+			if !ok {
+				return NewAPIResponseError(http.StatusNotAcceptable, fmt.Sprintf("Unsupported Accept: %q.", req.Request.Header.Get("Accept")))
+			}
+		}
+	} else {
+		produces := []string{restful.MIME_JSON, MIME_NDJSON}
+		if len(info.Produces) > 0 {
+			produces = nil
+			for _, produce := range info.Produces {
+				if matchContentType(produce, []string{restful.MIME_JSON, MIME_NDJSON}) {
+					produces = append(produces, produce)
+				}
+			}
+		}
+		var ok bool
+		contentType, ok = negotiateContentType(req.Request.Header.Get("Accept"), produces)
+		if !ok {
+			return NewAPIResponseError(http.StatusNotAcceptable, fmt.Sprintf("Unsupported Accept: %q.", req.Request.Header.Get("Accept")))
+		}
+	}
+	if debugEnabled(ctx) {
+		slog.DebugContext(ctx, fmt.Sprintf("Streaming response Content-Type: %s", contentType))
+	}
+
+	resp.Header().Set("Content-Type", contentType)
+	resp.WriteHeader(status)
+	resp.Flush()
+	markResponseCommitted(req)
+
+	var err error
+	switch info.outputStream {
+	case streamKindReader:
+		err = writeStreamReader(req, resp, output.(io.Reader))
+	default:
+		err = writeStreamItems(req, resp, reflect.ValueOf(output), info.outputStream, contentType == MIME_NDJSON)
+	}
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		slog.ErrorContext(ctx, fmt.Sprintf("Could not stream response: %v", err))
+	}
+	return nil
+}
+
+// writeStreamReader copies the reader to the response, flushing after each chunk.
+func writeStreamReader(req *restful.Request, resp *restful.Response, reader io.Reader) error {
+	ctx := req.Request.Context()
+
+	buffer := make([]byte, streamBufferSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			_, writeErr := resp.Write(buffer[:n])
+			if writeErr != nil {
+				return writeErr
+			}
+			resp.Flush()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeStreamItems writes the items of an "iter.Seq" or channel to the response, flushing after each item.
+//
+// If ndjson is true, then each item is written on its own line; otherwise, the items are written as a JSON array.
+func writeStreamItems(req *restful.Request, resp *restful.Response, outputValue reflect.Value, kind streamKind, ndjson bool) error {
+	ctx := req.Request.Context()
+
+	encoder := json.NewEncoder(resp)
+
+	if !ndjson {
+		if _, err := io.WriteString(resp, "["); err != nil {
+			return err
+		}
+	}
+
+	count := 0
+	var err error
+	writeItem := func(item reflect.Value) bool {
+		if !ndjson && count > 0 {
+			if _, err = io.WriteString(resp, ","); err != nil {
+				return false
+			}
+		}
+		count++
+
+		err = encoder.Encode(item.Interface())
+		if err != nil {
+			return false
+		}
+		resp.Flush()
+		return true
+	}
+
+	switch kind {
+	case streamKindSeq:
+		if !outputValue.IsNil() {
+			yieldType := outputValue.Type().In(0)
+			yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+				keepGoing := ctx.Err() == nil && writeItem(args[0])
+				return []reflect.Value{reflect.ValueOf(keepGoing)}
+			})
+			outputValue.Call([]reflect.Value{yield})
+		}
+	case streamKindChannel:
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: outputValue},
+		}
+		for !outputValue.IsNil() {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 0 || !ok {
+				break
+			}
+			if !writeItem(item) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if !ndjson {
+		if _, err := io.WriteString(resp, "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package restfulwrapper
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// RestfulFunctionValidation represents the validation constraints on a metadata field.
+//
+// These come from the "validate" struct tag, which is a ";"-separated list of constraints:
+// * min:${number}; the minimum value of a number.
+// * max:${number}; the maximum value of a number.
+// * minLength:${count}; the minimum length (in characters) of a string.
+// * maxLength:${count}; the maximum length (in characters) of a string.
+// * pattern:${regexp}; a regular expression that a string must match.
+// * enum:${value},${value},...; the list of allowed values.
+// * minItems:${count}; the minimum number of items in a slice.
+// * maxItems:${count}; the maximum number of items in a slice.
+//
+// For slices, the item constraints (everything except "minItems" and "maxItems") apply to each item.
+// Nil pointers are not validated, and neither are optional parameters that were not given (and have no default).
+type RestfulFunctionValidation struct {
+	FieldName string
+	Index     []int // This is the index sequence of the field within the metadata struct.
+	Minimum   *float64
+	Maximum   *float64
+	MinLength *int64
+	MaxLength *int64
+	Pattern   string
+	Enum      []string
+	MinItems  *int64
+	MaxItems  *int64
+
+	pattern  *regexp.Regexp                  // This is the compiled pattern, if any.
+	newError func(err error) error           // This wraps a validation failure in the appropriate API error.
+	isGiven  func(req *restful.Request) bool // This returns false if the field's parameter was not given; nil means that it is always validated.
+}
+
+// parseValidation parses the "validate" struct tag for the given field.
+func parseValidation(validateTagText string, field reflect.StructField) (*RestfulFunctionValidation, error) {
+	validation := &RestfulFunctionValidation{
+		FieldName: field.Name,
+	}
+
+	parseFloat := func(key string, value string) (*float64, error) {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate tag value for %s: %w", key, err)
+		}
+		return &v, nil
+	}
+	parseCount := func(key string, value string) (*int64, error) {
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate tag value for %s: %w", key, err)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("invalid validate tag value for %s: must not be negative", key)
+		}
+		return &v, nil
+	}
+
+	for _, tagPart := range strings.Split(validateTagText, ";") {
+		if tagPart == "" {
+			continue
+		}
+		tagPartParts := strings.SplitN(tagPart, ":", 2)
+		tagPartKey := tagPartParts[0]
+		if len(tagPartParts) < 2 || tagPartParts[1] == "" {
+			return nil, fmt.Errorf("missing validate tag value for %s", tagPartKey)
+		}
+		tagPartValue := tagPartParts[1]
+
+		var err error
+		switch tagPartKey {
+		case "min":
+			validation.Minimum, err = parseFloat(tagPartKey, tagPartValue)
+		case "max":
+			validation.Maximum, err = parseFloat(tagPartKey, tagPartValue)
+		case "minLength":
+			validation.MinLength, err = parseCount(tagPartKey, tagPartValue)
+		case "maxLength":
+			validation.MaxLength, err = parseCount(tagPartKey, tagPartValue)
+		case "pattern":
+			validation.Pattern = tagPartValue
+			validation.pattern, err = regexp.Compile(tagPartValue)
+			if err != nil {
+				err = fmt.Errorf("invalid validate tag value for %s: %w", tagPartKey, err)
+			}
+		case "enum":
+			for _, value := range strings.Split(tagPartValue, ",") {
+				validation.Enum = append(validation.Enum, strings.TrimSpace(value))
+			}
+		case "minItems":
+			validation.MinItems, err = parseCount(tagPartKey, tagPartValue)
+		case "maxItems":
+			validation.MaxItems, err = parseCount(tagPartKey, tagPartValue)
+		default:
+			return nil, fmt.Errorf("invalid validate tag: %s", tagPartKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure that the constraints make sense for the type of the field.
+	fieldType := field.Type
+	for fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	if validation.MinItems != nil || validation.MaxItems != nil {
+		if fieldType.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("minItems and maxItems require a slice; got %s", field.Type.String())
+		}
+	}
+	itemType := fieldType
+	if itemType.Kind() == reflect.Slice {
+		itemType = itemType.Elem()
+		for itemType.Kind() == reflect.Pointer {
+			itemType = itemType.Elem()
+		}
+	}
+	if validation.Minimum != nil || validation.Maximum != nil {
+		if !isNumberKind(itemType.Kind()) {
+			return nil, fmt.Errorf("min and max require a number; got %s", field.Type.String())
+		}
+	}
+	if validation.MinLength != nil || validation.MaxLength != nil || validation.pattern != nil {
+		if itemType.Kind() != reflect.String {
+			return nil, fmt.Errorf("minLength, maxLength, and pattern require a string; got %s", field.Type.String())
+		}
+	}
+	if len(validation.Enum) > 0 {
+		if itemType.Kind() != reflect.String && itemType.Kind() != reflect.Bool && !isNumberKind(itemType.Kind()) {
+			return nil, fmt.Errorf("enum requires a string, bool, or number; got %s", field.Type.String())
+		}
+	}
+
+	return validation, nil
+}
+
+// isNumberKind returns true if the kind is a number.
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// Validate checks the value of the field against the constraints.
+func (validation *RestfulFunctionValidation) Validate(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice {
+		if validation.MinItems != nil && int64(v.Len()) < *validation.MinItems {
+			return fmt.Errorf("must have at least %d items", *validation.MinItems)
+		}
+		if validation.MaxItems != nil && int64(v.Len()) > *validation.MaxItems {
+			return fmt.Errorf("must have at most %d items", *validation.MaxItems)
+		}
+		for i := range v.Len() {
+			err := validation.validateItem(v.Index(i))
+			if err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	return validation.validateItem(v)
+}
+
+// validateItem checks a single (non-slice) value against the item constraints.
+func (validation *RestfulFunctionValidation) validateItem(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if isNumberKind(v.Kind()) {
+		var number float64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			number = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			number = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			number = v.Float()
+		}
+		if validation.Minimum != nil && number < *validation.Minimum {
+			return fmt.Errorf("must be at least %s", strconv.FormatFloat(*validation.Minimum, 'f', -1, 64))
+		}
+		if validation.Maximum != nil && number > *validation.Maximum {
+			return fmt.Errorf("must be at most %s", strconv.FormatFloat(*validation.Maximum, 'f', -1, 64))
+		}
+	}
+
+	if v.Kind() == reflect.String {
+		length := int64(utf8.RuneCountInString(v.String()))
+		if validation.MinLength != nil && length < *validation.MinLength {
+			return fmt.Errorf("length must be at least %d", *validation.MinLength)
+		}
+		if validation.MaxLength != nil && length > *validation.MaxLength {
+			return fmt.Errorf("length must be at most %d", *validation.MaxLength)
+		}
+		if validation.pattern != nil && !validation.pattern.MatchString(v.String()) {
+			return fmt.Errorf("must match pattern %q", validation.Pattern)
+		}
+	}
+
+	if len(validation.Enum) > 0 {
+		stringValue := fmt.Sprintf("%v", v.Interface())
+		if !slices.Contains(validation.Enum, stringValue) {
+			return fmt.Errorf("must be one of: %s", strings.Join(validation.Enum, ", "))
+		}
+	}
+
+	return nil
+}
+
+// UpdateParameter adds the constraints to the parameter's documentation.
+func (validation *RestfulFunctionValidation) UpdateParameter(parameter *restful.Parameter) {
+	if validation.Minimum != nil {
+		parameter.Minimum(*validation.Minimum)
+	}
+	if validation.Maximum != nil {
+		parameter.Maximum(*validation.Maximum)
+	}
+	if validation.MinLength != nil {
+		parameter.MinLength(*validation.MinLength)
+	}
+	if validation.MaxLength != nil {
+		parameter.MaxLength(*validation.MaxLength)
+	}
+	if validation.Pattern != "" {
+		parameter.Pattern(validation.Pattern)
+	}
+	if len(validation.Enum) > 0 {
+		parameter.PossibleValues(validation.Enum)
+	}
+	if validation.MinItems != nil {
+		parameter.MinItems(*validation.MinItems)
+	}
+	if validation.MaxItems != nil {
+		parameter.MaxItems(*validation.MaxItems)
+	}
+}
+
+// updateSchema adds the constraints to a JSON Schema.
+//
+// This is for parameters that "restful" cannot document (such as cookies).
+func (validation *RestfulFunctionValidation) updateSchema(schema JSONSchema) {
+	applyOpenAPIParameterConstraints(schema, restful.ParameterData{
+		Minimum:        validation.Minimum,
+		Maximum:        validation.Maximum,
+		MinLength:      validation.MinLength,
+		MaxLength:      validation.MaxLength,
+		Pattern:        validation.Pattern,
+		PossibleValues: validation.Enum,
+		MinItems:       validation.MinItems,
+		MaxItems:       validation.MaxItems,
+	})
+}
+
+// newValidationErrorFunction returns a function that wraps a validation failure for the given field
+// in the API error that matches where the field's value came from.
+func (info *RestfulFunctionInfo) newValidationErrorFunction(apiTagKey string, fieldName string) (func(err error) error, error) {
+	for _, cookieParameter := range info.CookieParameters {
+		if cookieParameter.FieldName == fieldName {
+			return func(err error) error {
+				return NewAPICookieParameterError(cookieParameter.Name, err)
+			}, nil
+		}
+	}
+	for _, pathParameter := range info.PathParameters {
+		if pathParameter.FieldName == fieldName {
+			return func(err error) error {
+				return NewAPIPathParameterError(pathParameter.Name, err)
+			}, nil
+		}
+	}
+	for _, queryParameter := range info.QueryParameters {
+		if queryParameter.FieldName == fieldName {
+			return func(err error) error {
+				return NewAPIQueryParameterError(queryParameter.Name, err)
+			}, nil
+		}
+	}
+	for _, formParameter := range info.FormParameters {
+		if formParameter.FieldName == fieldName {
+			return func(err error) error {
+				return NewAPIFormParameterError(formParameter.Name, err)
+			}, nil
+		}
+	}
+	for _, headerParameter := range info.HeaderParameters {
+		if headerParameter.FieldName == fieldName {
+			return func(err error) error {
+				return NewAPIHeaderParameterError(headerParameter.Name, err)
+			}, nil
+		}
+	}
+	if apiTagKey == "body" {
+		return func(err error) error {
+			return NewAPIBodyError(fmt.Errorf("%s: %w", fieldName, err))
+		}, nil
+	}
+	return nil, fmt.Errorf("validate tag is not supported for API tag: %s", apiTagKey)
+}
+
+// newValidationGivenFunction returns a function that reports whether the parameter for the given field was
+// given in the request, so that an optional parameter that was left out is not validated.
+//
+// This returns nil if the field always has a value to validate (because it is required, has a default,
+// or is not an optional parameter).
+func (info *RestfulFunctionInfo) newValidationGivenFunction(field reflect.StructField) func(req *restful.Request) bool {
+	if _, hasDefault := field.Tag.Lookup("default"); hasDefault {
+		return nil
+	}
+	for _, cookieParameter := range info.CookieParameters {
+		if cookieParameter.FieldName == field.Name && !cookieParameter.Required {
+			return func(req *restful.Request) bool {
+				_, err := req.Request.Cookie(cookieParameter.Name)
+				return err == nil
+			}
+		}
+	}
+	var queryNames []string
+	for _, queryParameter := range info.QueryParameters {
+		if queryParameter.FieldName == field.Name {
+			if queryParameter.Required {
+				return nil
+			}
+			queryNames = append(queryNames, queryParameter.Name)
+		}
+	}
+	if len(queryNames) > 0 {
+		return func(req *restful.Request) bool {
+			query := req.Request.URL.Query()
+			return slices.ContainsFunc(queryNames, func(name string) bool { return len(query[name]) > 0 })
+		}
+	}
+	for _, formParameter := range info.FormParameters {
+		if formParameter.FieldName == field.Name && !formParameter.Required {
+			return func(req *restful.Request) bool {
+				return len(req.Request.PostForm[formParameter.Name]) > 0
+			}
+		}
+	}
+	for _, headerParameter := range info.HeaderParameters {
+		if headerParameter.FieldName == field.Name && !headerParameter.Required {
+			canonicalName := http.CanonicalHeaderKey(headerParameter.Name)
+			return func(req *restful.Request) bool {
+				return len(req.Request.Header[canonicalName]) > 0
+			}
+		}
+	}
+	return nil
+}
+
+// validationForField returns the validation constraints for the given field, if any.
+func (info *RestfulFunctionInfo) validationForField(fieldName string) *RestfulFunctionValidation {
+	for _, validation := range info.Validations {
+		if validation.FieldName == fieldName {
+			return validation
+		}
+	}
+	return nil
+}
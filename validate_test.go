@@ -0,0 +1,326 @@
+package restfulwrapper
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		rows := []struct {
+			Description string
+			Target      any
+			Tag         string
+			Success     bool
+		}{
+			{
+				Description: "min and max on int",
+				Target:      int(0),
+				Tag:         "min:1;max:100",
+				Success:     true,
+			},
+			{
+				Description: "min on string",
+				Target:      "",
+				Tag:         "min:1",
+				Success:     false,
+			},
+			{
+				Description: "bad min",
+				Target:      int(0),
+				Tag:         "min:abc",
+				Success:     false,
+			},
+			{
+				Description: "missing value",
+				Target:      int(0),
+				Tag:         "min",
+				Success:     false,
+			},
+			{
+				Description: "string constraints",
+				Target:      "",
+				Tag:         "minLength:1;maxLength:5;pattern:^[a-z]+$",
+				Success:     true,
+			},
+			{
+				Description: "negative length",
+				Target:      "",
+				Tag:         "minLength:-1",
+				Success:     false,
+			},
+			{
+				Description: "bad pattern",
+				Target:      "",
+				Tag:         "pattern:[",
+				Success:     false,
+			},
+			{
+				Description: "pattern on int",
+				Target:      int(0),
+				Tag:         "pattern:^a$",
+				Success:     false,
+			},
+			{
+				Description: "enum on string",
+				Target:      "",
+				Tag:         "enum:a,b,c",
+				Success:     true,
+			},
+			{
+				Description: "items on slice",
+				Target:      []int{},
+				Tag:         "minItems:1;maxItems:3;min:0",
+				Success:     true,
+			},
+			{
+				Description: "items on int",
+				Target:      int(0),
+				Tag:         "minItems:1",
+				Success:     false,
+			},
+			{
+				Description: "pointer int",
+				Target:      new(int),
+				Tag:         "min:1",
+				Success:     true,
+			},
+			{
+				Description: "bogus constraint",
+				Target:      int(0),
+				Tag:         "bogus:1",
+				Success:     false,
+			},
+		}
+		for _, row := range rows {
+			t.Run(row.Description, func(t *testing.T) {
+				field := reflect.StructField{
+					Name: "Field",
+					Type: reflect.TypeOf(row.Target),
+				}
+				validation, err := parseValidation(row.Tag, field)
+				if row.Success {
+					require.Nil(t, err)
+					assert.NotNil(t, validation)
+				} else {
+					require.NotNil(t, err)
+					assert.Nil(t, validation)
+				}
+			})
+		}
+	})
+	t.Run("Validate", func(t *testing.T) {
+		rows := []struct {
+			Description string
+			Tag         string
+			Value       any
+			Success     bool
+		}{
+			{
+				Description: "int within range",
+				Tag:         "min:1;max:10",
+				Value:       int(5),
+				Success:     true,
+			},
+			{
+				Description: "int below minimum",
+				Tag:         "min:1;max:10",
+				Value:       int(0),
+				Success:     false,
+			},
+			{
+				Description: "int above maximum",
+				Tag:         "min:1;max:10",
+				Value:       int(11),
+				Success:     false,
+			},
+			{
+				Description: "float above maximum",
+				Tag:         "max:1.5",
+				Value:       float64(1.6),
+				Success:     false,
+			},
+			{
+				Description: "nil pointer is skipped",
+				Tag:         "min:1",
+				Value:       (*int)(nil),
+				Success:     true,
+			},
+			{
+				Description: "string too short",
+				Tag:         "minLength:2",
+				Value:       "a",
+				Success:     false,
+			},
+			{
+				Description: "string length counts characters",
+				Tag:         "maxLength:2",
+				Value:       "éé",
+				Success:     true,
+			},
+			{
+				Description: "string does not match pattern",
+				Tag:         "pattern:^[a-z]+$",
+				Value:       "ABC",
+				Success:     false,
+			},
+			{
+				Description: "string in enum",
+				Tag:         "enum:asc,desc",
+				Value:       "asc",
+				Success:     true,
+			},
+			{
+				Description: "string not in enum",
+				Tag:         "enum:asc,desc",
+				Value:       "up",
+				Success:     false,
+			},
+			{
+				Description: "int in enum",
+				Tag:         "enum:1,2,3",
+				Value:       int(2),
+				Success:     true,
+			},
+			{
+				Description: "too few items",
+				Tag:         "minItems:2",
+				Value:       []string{"a"},
+				Success:     false,
+			},
+			{
+				Description: "too many items",
+				Tag:         "maxItems:1",
+				Value:       []string{"a", "b"},
+				Success:     false,
+			},
+			{
+				Description: "item constraint",
+				Tag:         "maxItems:3;enum:a,b",
+				Value:       []string{"a", "c"},
+				Success:     false,
+			},
+		}
+		for _, row := range rows {
+			t.Run(row.Description, func(t *testing.T) {
+				field := reflect.StructField{
+					Name: "Field",
+					Type: reflect.TypeOf(row.Value),
+				}
+				validation, err := parseValidation(row.Tag, field)
+				require.Nil(t, err)
+
+				err = validation.Validate(reflect.ValueOf(row.Value))
+				if row.Success {
+					assert.Nil(t, err)
+				} else {
+					assert.NotNil(t, err)
+				}
+			})
+		}
+	})
+	t.Run("Parameter errors", func(t *testing.T) {
+		input := func(struct {
+			Path   int      `api:"path:id" validate:"min:1"`
+			Query  string   `api:"query:sort,order" validate:"enum:asc,desc"`
+			Header string   `api:"header:X-Key" validate:"minLength:3"`
+			Body   []string `api:"body" validate:"maxItems:2"`
+		}) {
+		}
+		output, err := ParseRestfulFunction(input)
+		require.Nil(t, err)
+		require.NotNil(t, output)
+
+		if assert.Equal(t, 4, len(output.Validations)) {
+			baseErr := &APIPathParameterError{}
+			if assert.ErrorAs(t, output.Validations[0].newError(assert.AnError), &baseErr) {
+				assert.Equal(t, "id", baseErr.parameter)
+			}
+			queryErr := &APIQueryParameterError{}
+			if assert.ErrorAs(t, output.Validations[1].newError(assert.AnError), &queryErr) {
+				assert.Equal(t, "sort", queryErr.parameter)
+			}
+			headerErr := &APIHeaderParameterError{}
+			if assert.ErrorAs(t, output.Validations[2].newError(assert.AnError), &headerErr) {
+				assert.Equal(t, "X-Key", headerErr.parameter)
+			}
+			bodyErr := &APIBodyError{}
+			if assert.ErrorAs(t, output.Validations[3].newError(assert.AnError), &bodyErr) {
+				assert.Contains(t, bodyErr.Error(), "Body")
+			}
+		}
+	})
+	t.Run("Unsupported tag", func(t *testing.T) {
+		input := func(struct {
+			Doc string `api:"doc" validate:"minLength:3"`
+		}) {
+		}
+		output, err := ParseRestfulFunction(input)
+		require.NotNil(t, err)
+		assert.Nil(t, output)
+	})
+	t.Run("Absent optional parameters", func(t *testing.T) {
+		type Metadata struct {
+			Limit  int    `api:"query:limit" validate:"min:1"`
+			Page   int    `api:"query:page" default:"0" validate:"min:1"`
+			Key    string `api:"header:X-Key" validate:"minLength:3"`
+			Cookie string `api:"cookie:session" validate:"minLength:3"`
+		}
+		info, err := ParseRestfulFunction(func(meta Metadata) {})
+		require.Nil(t, err)
+
+		rows := []struct {
+			Description string
+			Query       string
+			Header      string
+			Success     bool
+		}{
+			{Description: "absent without default", Query: "page=1", Success: true},
+			{Description: "given and invalid", Query: "page=1&limit=0", Success: false},
+			{Description: "absent with an invalid default", Query: "", Success: false},
+			{Description: "given header and invalid", Query: "page=1", Header: "ab", Success: false},
+		}
+		for _, row := range rows {
+			t.Run(row.Description, func(t *testing.T) {
+				httpRequest, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/?"+row.Query, nil)
+				require.Nil(t, err)
+				if row.Header != "" {
+					httpRequest.Header.Set("X-Key", row.Header)
+				}
+
+				var meta Metadata
+				err = info.bindMetadata(restful.NewRequest(httpRequest), reflect.ValueOf(&meta).Elem())
+				if row.Success {
+					assert.Nil(t, err)
+				} else {
+					assert.NotNil(t, err)
+				}
+			})
+		}
+	})
+	t.Run("Documentation", func(t *testing.T) {
+		input := func(struct {
+			Limit int `api:"query:limit" validate:"min:1;max:100"`
+		}) {
+		}
+		output, err := ParseRestfulFunction(input)
+		require.Nil(t, err)
+
+		routeBuilder := new(restful.WebService).GET("/")
+		output.UpdateRouteBuilder(routeBuilder)
+
+		parameter := routeBuilder.ParameterNamed("limit")
+		require.NotNil(t, parameter)
+		if assert.NotNil(t, parameter.Data().Minimum) {
+			assert.Equal(t, float64(1), *parameter.Data().Minimum)
+		}
+		if assert.NotNil(t, parameter.Data().Maximum) {
+			assert.Equal(t, float64(100), *parameter.Data().Maximum)
+		}
+	})
+}
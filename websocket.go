@@ -0,0 +1,373 @@
+package restfulwrapper
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// WebSocket is a WebSocket connection that sends and receives messages of type T.
+//
+// Use it as a `api:"websocket"` field of the metadata of an HTTPMethodWEBSOCKET endpoint.  Strings are sent and
+// received as text messages, byte slices as binary messages, and anything else as JSON text messages.
+//
+// Receive must only be called from one goroutine at a time; Send may be called from any number of goroutines.
+type WebSocket[T any] struct {
+	conn *webSocketConn
+}
+
+// Receive waits for the next message.
+//
+// This returns io.EOF once the client has closed the connection.
+func (w *WebSocket[T]) Receive() (T, error) {
+	var message T
+
+	_, payload, err := w.conn.readMessage()
+	if err != nil {
+		return message, err
+	}
+
+	switch target := any(&message).(type) {
+	case *string:
+		*target = string(payload)
+	case *[]byte:
+		*target = payload
+	default:
+		err = json.Unmarshal(payload, &message)
+		if err != nil {
+			return message, fmt.Errorf("could not decode message: %w", err)
+		}
+	}
+	return message, nil
+}
+
+// Send sends a message.
+func (w *WebSocket[T]) Send(message T) error {
+	switch value := any(message).(type) {
+	case string:
+		return w.conn.writeFrame(webSocketOpcodeText, []byte(value))
+	case []byte:
+		return w.conn.writeFrame(webSocketOpcodeBinary, value)
+	default:
+		payload, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("could not encode message: %w", err)
+		}
+		return w.conn.writeFrame(webSocketOpcodeText, payload)
+	}
+}
+
+// bindWebSocket sets the connection of the WebSocket.
+func (w *WebSocket[T]) bindWebSocket(conn *webSocketConn) {
+	w.conn = conn
+}
+
+// webSocketBinder is implemented by all WebSocket types.
+type webSocketBinder interface {
+	bindWebSocket(conn *webSocketConn)
+}
+
+var webSocketBinderType = reflect.TypeOf((*webSocketBinder)(nil)).Elem()
+
+// webSocketAttribute is the request attribute that holds the WebSocket field until the connection is upgraded.
+const webSocketAttribute = "restfulwrapper.websocket"
+
+// webSocketGUID is the magic value from RFC 6455 that is used to compute "Sec-WebSocket-Accept".
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// defaultWebSocketMaxMessageSize is the maximum size of a received message, in bytes, unless the route has
+// its own maximum body size.
+const defaultWebSocketMaxMessageSize = 32 * 1000 * 1000 // 32MB.
+
+// These are the WebSocket frame opcodes.
+const (
+	webSocketOpcodeContinuation byte = 0x0
+	webSocketOpcodeText         byte = 0x1
+	webSocketOpcodeBinary       byte = 0x2
+	webSocketOpcodeClose        byte = 0x8
+	webSocketOpcodePing         byte = 0x9
+	webSocketOpcodePong         byte = 0xA
+)
+
+// These are the WebSocket close status codes.
+const (
+	webSocketCloseNormal          = 1000
+	webSocketCloseProtocolError   = 1002
+	webSocketCloseInvalidData     = 1007
+	webSocketCloseMessageTooBig   = 1009
+	webSocketCloseInternalError   = 1011
+	webSocketCloseNoStatusPresent = 1005
+)
+
+// webSocketConn is a server-side WebSocket connection (RFC 6455).
+type webSocketConn struct {
+	netConn        net.Conn
+	reader         *bufio.Reader
+	writer         *bufio.Writer
+	maxMessageSize int64
+
+	writeMutex sync.Mutex // This protects the writer and closeSent.
+	closeSent  bool       // This is true once a close frame has been sent; nothing else may be sent after that.
+}
+
+// upgradeWebSocket upgrades the request to a WebSocket connection and binds it to the WebSocket field that
+// was set when the metadata was bound.
+//
+// If the request is not a valid WebSocket upgrade request, then this returns an error without writing anything.
+func (info *RestfulFunctionInfo) upgradeWebSocket(req *restful.Request, resp *restful.Response) (*webSocketConn, error) {
+	if !headerContainsToken(req.Request.Header, "Connection", "upgrade") || !headerContainsToken(req.Request.Header, "Upgrade", "websocket") {
+		return nil, NewAPIResponseError(http.StatusUpgradeRequired, "WebSocket upgrade required.")
+	}
+	if req.Request.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, NewAPIResponseError(http.StatusBadRequest, "Unsupported WebSocket version.")
+	}
+	key := req.Request.Header.Get("Sec-WebSocket-Key")
+	if decodedKey, err := base64.StdEncoding.DecodeString(key); err != nil || len(decodedKey) != 16 {
+		return nil, NewAPIResponseError(http.StatusBadRequest, "Invalid WebSocket key.")
+	}
+
+	binder, ok := req.Attribute(webSocketAttribute).(webSocketBinder)
+	if !ok {
+		return nil, fmt.Errorf("missing websocket field")
+	}
+
+	netConn, readWriter, err := resp.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("could not hijack connection: %w", err)
+	}
+	markResponseCommitted(req)
+
+	acceptHash := sha1.Sum([]byte(key + webSocketGUID))
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(acceptHash[:]) + "\r\n" +
+		"\r\n"
+	_, err = readWriter.WriteString(handshake)
+	if err == nil {
+		err = readWriter.Flush()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("could not write websocket handshake: %w", err)
+	}
+
+	maxMessageSize := info.MaxBodyBytes
+	if maxMessageSize <= 0 {
+		maxMessageSize = defaultWebSocketMaxMessageSize
+	}
+	conn := &webSocketConn{
+		netConn:        netConn,
+		reader:         readWriter.Reader,
+		writer:         readWriter.Writer,
+		maxMessageSize: maxMessageSize,
+	}
+	binder.bindWebSocket(conn)
+	return conn, nil
+}
+
+// finish closes the connection once the method has returned.
+//
+// If the method failed, then the error is logged (since it can no longer be written as a response).
+func (c *webSocketConn) finish(ctx context.Context, err error) {
+	code := webSocketCloseNormal
+	if err != nil && !errors.Is(err, io.EOF) {
+		slog.ErrorContext(ctx, fmt.Sprintf("WebSocket method failed: %v", err))
+		code = webSocketCloseInternalError
+	}
+	c.close(code, "")
+}
+
+// close sends a close frame (if one has not already been sent) and closes the underlying connection.
+func (c *webSocketConn) close(code int, reason string) {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	_ = c.writeFrame(webSocketOpcodeClose, payload)
+	c.netConn.Close()
+}
+
+// readMessage reads the next data message, handling any control frames along the way.
+//
+// This returns io.EOF once the client has closed the connection.
+func (c *webSocketConn) readMessage() (byte, []byte, error) {
+	var messageOpcode byte
+	var message []byte
+	started := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case webSocketOpcodeClose:
+			code := webSocketCloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			if code == webSocketCloseNoStatusPresent {
+				code = webSocketCloseNormal
+			}
+			c.close(code, "")
+			return 0, nil, io.EOF
+		case webSocketOpcodePing:
+			err = c.writeFrame(webSocketOpcodePong, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case webSocketOpcodePong:
+			continue
+		case webSocketOpcodeText, webSocketOpcodeBinary:
+			if started {
+				return 0, nil, c.fail(webSocketCloseProtocolError, "new message before the previous one finished")
+			}
+			started = true
+			messageOpcode = opcode
+			message = payload
+		case webSocketOpcodeContinuation:
+			if !started {
+				return 0, nil, c.fail(webSocketCloseProtocolError, "continuation without a message")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, c.fail(webSocketCloseProtocolError, fmt.Sprintf("unknown opcode: %d", opcode))
+		}
+
+		if int64(len(message)) > c.maxMessageSize {
+			return 0, nil, c.fail(webSocketCloseMessageTooBig, "message too big")
+		}
+		if fin {
+			if messageOpcode == webSocketOpcodeText && !utf8.Valid(message) {
+				return 0, nil, c.fail(webSocketCloseInvalidData, "invalid UTF-8 in text message")
+			}
+			return messageOpcode, message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame.
+func (c *webSocketConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	_, err := io.ReadFull(c.reader, header[:])
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(webSocketCloseProtocolError, "reserved bits set")
+	}
+	if !masked {
+		return false, 0, nil, c.fail(webSocketCloseProtocolError, "client frames must be masked")
+	}
+
+	switch length {
+	case 126:
+		var extendedLength [2]byte
+		_, err = io.ReadFull(c.reader, extendedLength[:])
+		length = uint64(binary.BigEndian.Uint16(extendedLength[:]))
+	case 127:
+		var extendedLength [8]byte
+		_, err = io.ReadFull(c.reader, extendedLength[:])
+		length = binary.BigEndian.Uint64(extendedLength[:])
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	if opcode >= webSocketOpcodeClose && (!fin || length > 125) {
+		return false, 0, nil, c.fail(webSocketCloseProtocolError, "invalid control frame")
+	}
+	if length > uint64(c.maxMessageSize) {
+		return false, 0, nil, c.fail(webSocketCloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	_, err = io.ReadFull(c.reader, mask[:])
+	if err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// fail closes the connection with the given status code and returns an error describing why.
+func (c *webSocketConn) fail(code int, reason string) error {
+	c.close(code, reason)
+	return fmt.Errorf("websocket protocol error: %s", reason)
+}
+
+// writeFrame writes a single, final, unmasked frame.
+func (c *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if opcode == webSocketOpcodeClose {
+		c.closeSent = true
+	}
+
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	_, err := c.writer.Write(header)
+	if err == nil {
+		_, err = c.writer.Write(payload)
+	}
+	if err == nil {
+		err = c.writer.Flush()
+	}
+	return err
+}
+
+// headerContainsToken returns true if the comma-separated header contains the token (case-insensitively).
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for part := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
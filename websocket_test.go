@@ -0,0 +1,294 @@
+package restfulwrapper
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webSocketTestMessage struct {
+	Room int    `json:"room"`
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+type webSocketTestMetadata struct {
+	HTTPMethodWEBSOCKET
+	_    string                           `api:"httppath:/chat/{room}"`
+	Room int                              `api:"path:room"`
+	Name string                           `api:"query:name;required"`
+	Conn *WebSocket[webSocketTestMessage] `api:"websocket"`
+}
+
+type webSocketTestAPI struct {
+	methodErrors chan error // This receives the result of each connection.
+}
+
+func (a *webSocketTestAPI) Chat(ctx context.Context, meta webSocketTestMetadata) error {
+	for {
+		message, err := meta.Conn.Receive()
+		if errors.Is(err, io.EOF) {
+			a.methodErrors <- nil
+			return nil
+		}
+		if err != nil {
+			a.methodErrors <- err
+			return err
+		}
+		message.Room = meta.Room
+		message.Name = meta.Name
+		err = meta.Conn.Send(message)
+		if err != nil {
+			a.methodErrors <- err
+			return err
+		}
+	}
+}
+
+type webSocketTestCrashMetadata struct {
+	HTTPMethodWEBSOCKET
+	_    string             `api:"httppath:/crash"`
+	Conn *WebSocket[string] `api:"websocket"`
+}
+
+func (a *webSocketTestAPI) Crash(ctx context.Context, meta webSocketTestCrashMetadata) error {
+	panic("crash")
+}
+
+// webSocketTestClient is a minimal WebSocket client for testing.
+type webSocketTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocketTest opens a WebSocket connection to the given URL path.
+func dialWebSocketTest(t *testing.T, server *httptest.Server, path string) (*webSocketTestClient, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	require.Nil(t, err)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	err = req.Write(conn)
+	require.Nil(t, err)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	require.Nil(t, err)
+
+	return &webSocketTestClient{conn: conn, reader: reader}, resp
+}
+
+// writeFrame writes a single masked frame.
+func (c *webSocketTestClient) writeFrame(t *testing.T, fin bool, opcode byte, payload []byte) {
+	header := []byte{opcode}
+	if fin {
+		header[0] |= 0x80
+	}
+	switch {
+	case len(payload) < 126:
+		header = append(header, 0x80|byte(len(payload)))
+	default:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	header = append(header, mask...)
+
+	masked := make([]byte, len(payload))
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+
+	_, err := c.conn.Write(append(header, masked...))
+	require.Nil(t, err)
+}
+
+// readFrame reads a single unmasked frame.
+func (c *webSocketTestClient) readFrame(t *testing.T) (byte, []byte) {
+	var header [2]byte
+	_, err := io.ReadFull(c.reader, header[:])
+	require.Nil(t, err)
+	require.Equal(t, byte(0x80), header[0]&0x80, "the frame must be final")
+	require.Equal(t, byte(0), header[1]&0x80, "the frame must not be masked")
+
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extendedLength [2]byte
+		_, err = io.ReadFull(c.reader, extendedLength[:])
+		require.Nil(t, err)
+		length = int(binary.BigEndian.Uint16(extendedLength[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	require.Nil(t, err)
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocket(t *testing.T) {
+	ctx := t.Context()
+
+	api := &webSocketTestAPI{
+		methodErrors: make(chan error, 10),
+	}
+	webService := WebService("/api").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+	webService.Register(ctx, "/v1", api)
+
+	container := restful.NewContainer()
+	container.Add(webService.WebService())
+
+	server := httptest.NewServer(container)
+	defer server.Close()
+
+	t.Run("Routes", func(t *testing.T) {
+		routes := webService.WebService().Routes()
+		require.Equal(t, 2, len(routes))
+		for _, route := range routes {
+			assert.Equal(t, http.MethodGet, route.Method)
+			assert.Contains(t, route.ResponseErrors, http.StatusSwitchingProtocols)
+		}
+	})
+	t.Run("Not an upgrade", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/chat/5?name=bob", nil)
+		require.Nil(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+
+		var output map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIResponseError`, output["type"])
+	})
+	t.Run("Bad path parameter", func(t *testing.T) {
+		_, resp := dialWebSocketTest(t, server, "/api/v1/chat/bogus?name=bob")
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var output map[string]any
+		err := json.NewDecoder(resp.Body).Decode(&output)
+		require.Nil(t, err)
+		assert.Equal(t, `*restfulwrapper.APIPathParameterError`, output["type"])
+	})
+	t.Run("Missing query parameter", func(t *testing.T) {
+		_, resp := dialWebSocketTest(t, server, "/api/v1/chat/5")
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+	t.Run("Chat", func(t *testing.T) {
+		client, resp := dialWebSocketTest(t, server, "/api/v1/chat/5?name=bob")
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+		// A message in a single frame.
+		client.writeFrame(t, true, webSocketOpcodeText, []byte(`{"text":"hello"}`))
+		opcode, payload := client.readFrame(t)
+		assert.Equal(t, webSocketOpcodeText, opcode)
+		assert.JSONEq(t, `{"room":5,"name":"bob","text":"hello"}`, string(payload))
+
+		// A ping in the middle of a fragmented message.
+		client.writeFrame(t, false, webSocketOpcodeText, []byte(`{"text":`))
+		client.writeFrame(t, true, webSocketOpcodePing, []byte("ping"))
+		client.writeFrame(t, true, webSocketOpcodeContinuation, []byte(`"`+strings.Repeat("a", 200)+`"}`))
+		opcode, payload = client.readFrame(t)
+		assert.Equal(t, webSocketOpcodePong, opcode)
+		assert.Equal(t, "ping", string(payload))
+		opcode, payload = client.readFrame(t)
+		assert.Equal(t, webSocketOpcodeText, opcode)
+		assert.JSONEq(t, `{"room":5,"name":"bob","text":"`+strings.Repeat("a", 200)+`"}`, string(payload))
+
+		// Closing.
+		client.writeFrame(t, true, webSocketOpcodeClose, binary.BigEndian.AppendUint16(nil, webSocketCloseNormal))
+		opcode, payload = client.readFrame(t)
+		assert.Equal(t, webSocketOpcodeClose, opcode)
+		assert.Equal(t, uint16(webSocketCloseNormal), binary.BigEndian.Uint16(payload))
+		assert.Nil(t, <-api.methodErrors)
+	})
+	t.Run("Protocol error", func(t *testing.T) {
+		client, resp := dialWebSocketTest(t, server, "/api/v1/chat/5?name=bob")
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		client.writeFrame(t, true, webSocketOpcodeContinuation, []byte("oops"))
+		opcode, payload := client.readFrame(t)
+		assert.Equal(t, webSocketOpcodeClose, opcode)
+		assert.Equal(t, uint16(webSocketCloseProtocolError), binary.BigEndian.Uint16(payload[:2]))
+		assert.NotNil(t, <-api.methodErrors)
+	})
+	t.Run("Panic", func(t *testing.T) {
+		client, resp := dialWebSocketTest(t, server, "/api/v1/crash")
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+		opcode, payload := client.readFrame(t)
+		assert.Equal(t, webSocketOpcodeClose, opcode)
+		assert.Equal(t, uint16(webSocketCloseInternalError), binary.BigEndian.Uint16(payload))
+	})
+}
+
+func TestParseWebSocket(t *testing.T) {
+	t.Run("Missing websocket field", func(t *testing.T) {
+		input := func(struct {
+			HTTPMethodWEBSOCKET
+		}) error {
+			return nil
+		}
+		output, err := ParseRestfulFunction(input)
+		require.NotNil(t, err)
+		assert.Nil(t, output)
+	})
+	t.Run("Missing websocket endpoint", func(t *testing.T) {
+		input := func(struct {
+			HTTPMethodGET
+			Conn *WebSocket[string] `api:"websocket"`
+		}) error {
+			return nil
+		}
+		output, err := ParseRestfulFunction(input)
+		require.NotNil(t, err)
+		assert.Nil(t, output)
+	})
+	t.Run("Bad websocket type", func(t *testing.T) {
+		input := func(struct {
+			HTTPMethodWEBSOCKET
+			Conn WebSocket[string] `api:"websocket"`
+		}) error {
+			return nil
+		}
+		output, err := ParseRestfulFunction(input)
+		require.NotNil(t, err)
+		assert.Nil(t, output)
+	})
+	t.Run("Return value", func(t *testing.T) {
+		input := func(struct {
+			HTTPMethodWEBSOCKET
+			Conn *WebSocket[string] `api:"websocket"`
+		}) (string, error) {
+			return "", nil
+		}
+		output, err := ParseRestfulFunction(input)
+		require.NotNil(t, err)
+		assert.Nil(t, output)
+	})
+}
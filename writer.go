@@ -9,3 +9,10 @@ import "github.com/emicklei/go-restful/v3"
 type Writer interface {
 	Write(*restful.Response)
 }
+
+// StatusCoder can be used on an output type to set the status code of a successful response.
+//
+// This takes precedence over the "status" tag on the metadata.
+type StatusCoder interface {
+	StatusCode() int
+}